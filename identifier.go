@@ -4,14 +4,59 @@ import (
 	"fmt"
 	"slices"
 	"sort"
+	"strconv"
+	"strings"
 )
 
-func Identify(query string, options IdentifyOptions) (results []IdentifyResult, err error) {
+func Identify(query string, options IdentifyOptions) ([]IdentifyResult, error) {
+	identifyResults, _, err := identify(query, options)
+	return identifyResults, err
+}
+
+// identifies the statements of a query like Identify, grouping them into the batches separated by
+// MSSQL GO lines; queries without GO lines have a single batch
+func IdentifyBatches(query string, options IdentifyOptions) ([]Batch, error) {
+	identifyResults, result, err := identify(query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := []Batch{{Index: 0, Repeat: 1, Statements: []IdentifyResult{}}}
+	for _, token := range result.Tokens {
+		if token.Type == TokenBatchSeparator {
+			batches[len(batches)-1].Repeat = batchRepeat(token.Value)
+			batches = append(batches, Batch{Index: len(batches), Repeat: 1, Statements: []IdentifyResult{}})
+		}
+	}
+	for _, identifyResult := range identifyResults {
+		batches[identifyResult.Batch].Statements = append(batches[identifyResult.Batch].Statements, identifyResult)
+	}
+
+	// the script may end with a GO line, leaving nothing after it
+	if last := batches[len(batches)-1]; len(last.Statements) == 0 && len(batches) > 1 {
+		batches = batches[:len(batches)-1]
+	}
+	return batches, nil
+}
+
+// returns the repeat count of a GO [count] batch separator
+func batchRepeat(separator string) int {
+	count, err := strconv.Atoi(strings.TrimSpace(separator[2:]))
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+func identify(query string, options IdentifyOptions) (identifyResults []IdentifyResult, result *ParseResult, err error) {
+	// known failures are returned as a *ParseError; anything unexpected is still reported as an error
+	// rather than crashing the caller
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
+			identifyResults, result, err = nil, nil, fmt.Errorf("%v", r)
 		}
 	}()
+
 	isStrict := true
 	if options.Strict != nil {
 		isStrict = *options.Strict
@@ -25,7 +70,7 @@ func Identify(query string, options IdentifyOptions) (results []IdentifyResult,
 	isValidDialect := slices.Contains(DIALECTS, dialect)
 
 	if !isValidDialect {
-		return nil, fmt.Errorf("Unknown dialect. Allowed values: %v", DIALECTS)
+		return nil, nil, fmt.Errorf("Unknown dialect. Allowed values: %v", DIALECTS)
 	}
 
 	paramTypes := options.ParamTypes
@@ -38,10 +83,14 @@ func Identify(query string, options IdentifyOptions) (results []IdentifyResult,
 		identifyTables = *options.IdentifyTables
 	}
 
-	result := Parse(query, isStrict, dialect, identifyTables, paramTypes)
-	sortParams := dialect == DialectPSQL && options.ParamTypes == nil
+	result, err = Parse(query, isStrict, dialect, identifyTables, paramTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	sortParams := baseDialect(dialect) == DialectPSQL && options.ParamTypes == nil
 
-	identifyResults := make([]IdentifyResult, len(result.Body))
+	source := newSourceIndex(query)
+	identifyResults = make([]IdentifyResult, len(result.Body))
 	for i, statement := range result.Body {
 		// sorting the postgres params: $1 $2 $3, regardless of the order they appear
 		parameters := statement.Parameters
@@ -49,18 +98,35 @@ func Identify(query string, options IdentifyOptions) (results []IdentifyResult,
 			sort.Strings(parameters)
 		}
 
+		startByte := source.byteStart(statement.Start)
+		endByte := source.byteEnd(statement.End)
+		startLine, startColumn := source.lineColumn(statement.Start)
+		endLine, endColumn := source.lineColumn(statement.End)
+
 		identifyResults[i] = IdentifyResult{
 			Start:         statement.Start,
 			End:           statement.End,
-			Text:          query[statement.Start:min(statement.End+1, len(query))],
+			StartByte:     startByte,
+			EndByte:       endByte,
+			StartLine:     startLine,
+			StartColumn:   startColumn,
+			EndLine:       endLine,
+			EndColumn:     endColumn,
+			Text:          query[startByte : endByte+1],
 			Type:          statement.Type,
 			ExecutionType: statement.ExecutionType,
 			Parameters:    parameters,
 			Tables:        statement.Tables,
+			TableRefs:     statement.TableRefs,
+			CTEs:          statement.CTEs,
+			ModifyingCTEs: statement.ModifyingCTEs,
+			Upsert:        statement.Upsert,
+			Batch:         statement.Batch,
+			CopyData:      statement.CopyData,
 		}
 	}
 
-	return identifyResults, nil
+	return identifyResults, result, nil
 }
 
 func GetExecutionType(command StatementType) ExecutionType {
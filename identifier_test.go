@@ -1,8 +1,10 @@
 package sqlqueryidentifier
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -14,6 +16,9 @@ var (
 	strict = func(b bool) *bool {
 		return &b
 	}
+	withTables = func(b bool) *bool {
+		return &b
+	}
 )
 
 var AllDialects = []Dialect{
@@ -45,11 +50,27 @@ func assertIdentifyResults(t *testing.T, query string, options IdentifyOptions,
 		t.Fatalf("Unexpected error: %v.\nQuery: %q\nOptions: %#v", err, query, options)
 	}
 
+	expected = slices.Clone(expected)
+	for i := range expected {
+		if expected[i].StartLine == 0 {
+			expected[i] = withPositions(query, expected[i])
+		}
+	}
+
 	if !reflect.DeepEqual(actual, expected) {
 		t.Errorf("\nExpected: %#v\nBut got:  %#v\nQuery: %q\nOptions: %#v", expected, actual, query, options)
 	}
 }
 
+// fills in the byte offsets and line/column positions of a result from its rune offsets
+func withPositions(query string, result IdentifyResult) IdentifyResult {
+	token := withPosition(query, Token{Start: result.Start, End: result.End})
+	result.StartByte, result.EndByte = token.StartByte, token.EndByte
+	result.StartLine, result.StartColumn = token.StartLine, token.StartColumn
+	result.EndLine, result.EndColumn = token.EndLine, token.EndColumn
+	return result
+}
+
 type identifyTestCase struct {
 	name          string
 	query         string
@@ -324,10 +345,10 @@ func TestIdentify(t *testing.T) {
 						} else {
 							switch d {
 							case DialectSQLite:
-								expectedError := `instead of type="unknown" value="OR" (currentStep=1)`
+								expectedError := `instead of type="keyword" value="OR" (currentStep=1)`
 								assertIdentifyResults(t, query, options, nil, expectedError)
 							case DialectMSSQL:
-								expectedError := `instead of type="unknown" value="REPLACE" (currentStep=1)`
+								expectedError := `instead of type="keyword" value="REPLACE" (currentStep=1)`
 								assertIdentifyResults(t, query, options, nil, expectedError)
 							}
 						}
@@ -356,7 +377,7 @@ func TestIdentify(t *testing.T) {
 						if supportedDialects[d] {
 							assertIdentifyResults(t, query, options, []IdentifyResult{expectedResult}, "")
 						} else {
-							expectedError := `instead of type="unknown" value="TEMP" (currentStep=1)`
+							expectedError := `instead of type="identifier" value="TEMP" (currentStep=1)`
 							assertIdentifyResults(t, query, options, nil, expectedError)
 						}
 					})
@@ -384,7 +405,7 @@ func TestIdentify(t *testing.T) {
 						if supportedDialects[d] {
 							assertIdentifyResults(t, query, options, []IdentifyResult{expectedResult}, "")
 						} else {
-							expectedError := `instead of type="unknown" value="TEMPORARY" (currentStep=1)`
+							expectedError := `instead of type="identifier" value="TEMPORARY" (currentStep=1)`
 							assertIdentifyResults(t, query, options, nil, expectedError)
 						}
 					})
@@ -659,11 +680,11 @@ func TestIdentify(t *testing.T) {
 							var expectedError string
 							switch d {
 							case DialectSQLite:
-								expectedError = `instead of type="unknown" value="OR" (currentStep=1)`
+								expectedError = `instead of type="keyword" value="OR" (currentStep=1)`
 							case DialectMSSQL:
-								expectedError = `instead of type="unknown" value="REPLACE" (currentStep=1)`
+								expectedError = `instead of type="keyword" value="REPLACE" (currentStep=1)`
 							default:
-								expectedError = `instead of type="unknown" value="OR" (currentStep=1)`
+								expectedError = `instead of type="keyword" value="OR" (currentStep=1)`
 							}
 							assertIdentifyResults(t, query, options, nil, expectedError)
 						}
@@ -2277,8 +2298,8 @@ func TestIdentify(t *testing.T) {
 						Start:         0,
 						End:           17,
 						Text:          "BEGIN TRANSACTION;",
-						Type:          StatementUnknown,
-						ExecutionType: ExecutionUnknown,
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
 						Parameters:    []string{},
 						Tables:        []string{},
 					},
@@ -2295,6 +2316,253 @@ func TestIdentify(t *testing.T) {
 						Start:         29,
 						End:           35,
 						Text:          "COMMIT;",
+						Type:          StatementCommit,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify psql BEGIN and END",
+				query:   "BEGIN;\nEND;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           5,
+						Text:          "BEGIN;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         7,
+						End:           10,
+						Text:          "END;",
+						Type:          StatementCommit,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify mysql START TRANSACTION and COMMIT WORK",
+				query:   "START TRANSACTION;\nCOMMIT WORK;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           17,
+						Text:          "START TRANSACTION;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         19,
+						End:           30,
+						Text:          "COMMIT WORK;",
+						Type:          StatementCommit,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify mssql BEGIN TRAN and SAVE TRAN",
+				query:   "BEGIN TRAN;\nSAVE TRAN before_update;\nROLLBACK TRAN;",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           10,
+						Text:          "BEGIN TRAN;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         12,
+						End:           35,
+						Text:          "SAVE TRAN before_update;",
+						Type:          StatementSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         37,
+						End:           50,
+						Text:          "ROLLBACK TRAN;",
+						Type:          StatementRollback,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify savepoint statements",
+				query:   "SAVEPOINT sp1;\nRELEASE SAVEPOINT sp1;\nROLLBACK TO SAVEPOINT sp1;\nROLLBACK WORK TO sp1;\nROLLBACK;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           13,
+						Text:          "SAVEPOINT sp1;",
+						Type:          StatementSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         15,
+						End:           36,
+						Text:          "RELEASE SAVEPOINT sp1;",
+						Type:          StatementReleaseSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         38,
+						End:           63,
+						Text:          "ROLLBACK TO SAVEPOINT sp1;",
+						Type:          StatementRollbackToSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         65,
+						End:           85,
+						Text:          "ROLLBACK WORK TO sp1;",
+						Type:          StatementRollbackToSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         87,
+						End:           95,
+						Text:          "ROLLBACK;",
+						Type:          StatementRollback,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify sqlite BEGIN and END TRANSACTION",
+				query:   "BEGIN;\nEND TRANSACTION;",
+				options: IdentifyOptions{Dialect: dialect(DialectSQLite)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           5,
+						Text:          "BEGIN;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         7,
+						End:           22,
+						Text:          "END TRANSACTION;",
+						Type:          StatementCommit,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify mssql ROLLBACK TRAN to a savepoint",
+				query:   "SAVE TRAN sp1;\nROLLBACK TRAN sp1;\nROLLBACK TRANSACTION sp1;\nROLLBACK TRAN;",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           13,
+						Text:          "SAVE TRAN sp1;",
+						Type:          StatementSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         15,
+						End:           32,
+						Text:          "ROLLBACK TRAN sp1;",
+						Type:          StatementRollbackToSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         34,
+						End:           58,
+						Text:          "ROLLBACK TRANSACTION sp1;",
+						Type:          StatementRollbackToSavepoint,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         60,
+						End:           73,
+						Text:          "ROLLBACK TRAN;",
+						Type:          StatementRollback,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should not identify mysql START SLAVE or START REPLICA as a transaction",
+				query:   "START TRANSACTION;\nSTART SLAVE;\nSTART REPLICA;\nSTART GROUP_REPLICATION;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), Strict: strict(false)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           17,
+						Text:          "START TRANSACTION;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         19,
+						End:           30,
+						Text:          "START SLAVE;",
+						Type:          StatementUnknown,
+						ExecutionType: ExecutionUnknown,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         32,
+						End:           45,
+						Text:          "START REPLICA;",
+						Type:          StatementUnknown,
+						ExecutionType: ExecutionUnknown,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         47,
+						End:           70,
+						Text:          "START GROUP_REPLICATION;",
 						Type:          StatementUnknown,
 						ExecutionType: ExecutionUnknown,
 						Parameters:    []string{},
@@ -2324,8 +2592,8 @@ func TestIdentify(t *testing.T) {
 							Start:         0,
 							End:           18 + len(typeName),
 							Text:          stmt0Text,
-							Type:          StatementUnknown,
-							ExecutionType: ExecutionUnknown,
+							Type:          StatementBeginTransaction,
+							ExecutionType: ExecutionTransaction,
 							Parameters:    []string{},
 							Tables:        []string{},
 						},
@@ -2342,8 +2610,8 @@ func TestIdentify(t *testing.T) {
 							Start:         30 + len(typeName),
 							End:           36 + len(typeName),
 							Text:          stmt2Text,
-							Type:          StatementUnknown,
-							ExecutionType: ExecutionUnknown,
+							Type:          StatementCommit,
+							ExecutionType: ExecutionTransaction,
 							Parameters:    []string{},
 							Tables:        []string{},
 						},
@@ -2353,6 +2621,2882 @@ func TestIdentify(t *testing.T) {
 			}
 		})
 	})
+	t.Run("identify permission statements", func(t *testing.T) {
+		permissionTestCases := []identifyTestCase{
+			{
+				name:  "should identify GRANT and REVOKE",
+				query: "GRANT SELECT ON orders TO analyst;\nREVOKE INSERT ON orders FROM analyst;",
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           33,
+						Text:          "GRANT SELECT ON orders TO analyst;",
+						Type:          StatementGrant,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         35,
+						End:           71,
+						Text:          "REVOKE INSERT ON orders FROM analyst;",
+						Type:          StatementRevoke,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify user statements",
+				query:   "CREATE USER 'bob'@'%' IDENTIFIED BY 'secret';\nALTER USER 'bob'@'%' ACCOUNT LOCK;\nDROP USER 'bob'@'%';",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           44,
+						Text:          "CREATE USER 'bob'@'%' IDENTIFIED BY 'secret';",
+						Type:          StatementCreateUser,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         46,
+						End:           79,
+						Text:          "ALTER USER 'bob'@'%' ACCOUNT LOCK;",
+						Type:          StatementAlterUser,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         81,
+						End:           100,
+						Text:          "DROP USER 'bob'@'%';",
+						Type:          StatementDropUser,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify role statements",
+				query:   "CREATE ROLE analyst;\nALTER ROLE analyst WITH LOGIN;\nDROP ROLE analyst;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           19,
+						Text:          "CREATE ROLE analyst;",
+						Type:          StatementCreateRole,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         21,
+						End:           50,
+						Text:          "ALTER ROLE analyst WITH LOGIN;",
+						Type:          StatementAlterRole,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         52,
+						End:           69,
+						Text:          "DROP ROLE analyst;",
+						Type:          StatementDropRole,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify mysql SET PASSWORD",
+				query:   "SET PASSWORD FOR 'bob'@'%' = 'secret';",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           37,
+						Text:          "SET PASSWORD FOR 'bob'@'%' = 'secret';",
+						Type:          StatementSetPassword,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify psql ALTER ... OWNER TO",
+				query:   "ALTER TABLE orders OWNER TO analyst;\nALTER TABLE orders ADD COLUMN note text;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           35,
+						Text:          "ALTER TABLE orders OWNER TO analyst;",
+						Type:          StatementAlterOwner,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         37,
+						End:           76,
+						Text:          "ALTER TABLE orders ADD COLUMN note text;",
+						Type:          StatementAlterTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify psql OWNER TO on sequences, types and domains",
+				query:   "ALTER SEQUENCE s OWNER TO bob;\nALTER TYPE t OWNER TO bob;\nALTER DOMAIN d OWNER TO bob;\nALTER SEQUENCE s RESTART WITH 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           29,
+						Text:          "ALTER SEQUENCE s OWNER TO bob;",
+						Type:          StatementAlterOwner,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         31,
+						End:           56,
+						Text:          "ALTER TYPE t OWNER TO bob;",
+						Type:          StatementAlterOwner,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         58,
+						End:           85,
+						Text:          "ALTER DOMAIN d OWNER TO bob;",
+						Type:          StatementAlterOwner,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         87,
+						End:           118,
+						Text:          "ALTER SEQUENCE s RESTART WITH 1;",
+						Type:          StatementAlterSequence,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range permissionTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+
+		t.Run("should throw error for CREATE USER in sqlite", func(t *testing.T) {
+			assertIdentifyResults(t, "CREATE USER bob;", IdentifyOptions{Dialect: dialect(DialectSQLite)}, nil, `value="USER"`)
+		})
+	})
+
+	t.Run("identify table references", func(t *testing.T) {
+		tableTestCases := []identifyTestCase{
+			{
+				name:    "should split schema-qualified names and aliases",
+				query:   "SELECT * FROM sales.orders o JOIN \"Order Items\" AS oi ON o.id = oi.order_id",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           74,
+						Text:          "SELECT * FROM sales.orders o JOIN \"Order Items\" AS oi ON o.id = oi.order_id",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"sales.orders", `"Order Items"`},
+						TableRefs: []TableRef{
+							{Schema: "sales", Name: "orders", Alias: "o", Access: AccessRead},
+							{Name: "Order Items", Alias: "oi", NameQuoted: true, Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should unquote bracketed names and read comma separated tables",
+				query:   "SELECT * FROM [shop].[dbo].[Users] u, dbo.Roles",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           46,
+						Text:          "SELECT * FROM [shop].[dbo].[Users] u, dbo.Roles",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"[shop].[dbo].[Users]", "dbo.Roles"},
+						TableRefs: []TableRef{
+							{Catalog: "shop", Schema: "dbo", Name: "Users", Alias: "u", CatalogQuoted: true, SchemaQuoted: true, NameQuoted: true, Access: AccessRead},
+							{Schema: "dbo", Name: "Roles", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should split a quoted bigquery path",
+				query:   "SELECT * FROM `my-project.analytics.events`",
+				options: IdentifyOptions{Dialect: dialect(DialectBigQuery), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           42,
+						Text:          "SELECT * FROM `my-project.analytics.events`",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"`my-project.analytics.events`"},
+						TableRefs: []TableRef{
+							{Catalog: "my-project", Schema: "analytics", Name: "events", CatalogQuoted: true, SchemaQuoted: true, NameQuoted: true, Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should unescape doubled quotes",
+				query:   "INSERT INTO `shop`.`order``items` (id) VALUES (1)",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           48,
+						Text:          "INSERT INTO `shop`.`order``items` (id) VALUES (1)",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"`shop`.`order``items`"},
+						TableRefs: []TableRef{
+							{Schema: "shop", Name: "order`items", SchemaQuoted: true, NameQuoted: true, Access: AccessWrite},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the table of an UPDATE",
+				query:   "UPDATE accounts SET balance = 0 WHERE id = 1",
+				options: IdentifyOptions{IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           43,
+						Text:          "UPDATE accounts SET balance = 0 WHERE id = 1",
+						Type:          StatementUpdate,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"accounts"},
+						TableRefs: []TableRef{
+							{Name: "accounts", Access: AccessWrite},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of a DELETE with USING",
+				query:   "DELETE FROM users u USING sessions s WHERE u.id = s.user_id",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           58,
+						Text:          "DELETE FROM users u USING sessions s WHERE u.id = s.user_id",
+						Type:          StatementDelete,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"users", "sessions"},
+						TableRefs: []TableRef{
+							{Name: "users", Alias: "u", Access: AccessWrite},
+							{Name: "sessions", Alias: "s", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of a MySQL multi-table UPDATE",
+				query:   "UPDATE a JOIN b ON a.id = b.id SET a.x = b.x",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           43,
+						Text:          "UPDATE a JOIN b ON a.id = b.id SET a.x = b.x",
+						Type:          StatementUpdate,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"a", "b"},
+						TableRefs: []TableRef{
+							{Name: "a", Access: AccessWrite},
+							{Name: "b", Access: AccessWrite},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of a TRUNCATE",
+				query:   "TRUNCATE audit_log, events RESTART IDENTITY",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           42,
+						Text:          "TRUNCATE audit_log, events RESTART IDENTITY",
+						Type:          StatementTruncate,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"audit_log", "events"},
+						TableRefs: []TableRef{
+							{Name: "audit_log", Access: AccessWrite},
+							{Name: "events", Access: AccessWrite},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the table of an ALTER TABLE",
+				query:   "ALTER TABLE ONLY public.accounts ADD COLUMN note text",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           52,
+						Text:          "ALTER TABLE ONLY public.accounts ADD COLUMN note text",
+						Type:          StatementAlterTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"public.accounts"},
+						TableRefs: []TableRef{
+							{Schema: "public", Name: "accounts", Access: AccessDDL},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify every table of a DROP TABLE",
+				query:   "DROP TABLE IF EXISTS a, b CASCADE",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           32,
+						Text:          "DROP TABLE IF EXISTS a, b CASCADE",
+						Type:          StatementDropTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"a", "b"},
+						TableRefs: []TableRef{
+							{Name: "a", Access: AccessDDL},
+							{Name: "b", Access: AccessDDL},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the table of a CREATE INDEX",
+				query:   "CREATE UNIQUE INDEX idx ON orders (customer_id)",
+				options: IdentifyOptions{IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           46,
+						Text:          "CREATE UNIQUE INDEX idx ON orders (customer_id)",
+						Type:          StatementCreateIndex,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"orders"},
+						TableRefs: []TableRef{
+							{Name: "orders", Access: AccessDDL},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of a CREATE TABLE AS SELECT",
+				query:   "CREATE TABLE backup AS SELECT * FROM src",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           39,
+						Text:          "CREATE TABLE backup AS SELECT * FROM src",
+						Type:          StatementCreateTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"backup", "src"},
+						TableRefs: []TableRef{
+							{Name: "backup", Access: AccessDDL},
+							{Name: "src", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of a GRANT",
+				query:   "GRANT SELECT, INSERT ON TABLE orders, items TO analyst",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           53,
+						Text:          "GRANT SELECT, INSERT ON TABLE orders, items TO analyst",
+						Type:          StatementGrant,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{"orders", "items"},
+						TableRefs: []TableRef{
+							{Name: "orders", Access: AccessDDL},
+							{Name: "items", Access: AccessDDL},
+						},
+					},
+				},
+			},
+			{
+				name:    "should separate the written table from the read tables of an INSERT ... SELECT",
+				query:   "INSERT INTO archive SELECT * FROM live",
+				options: IdentifyOptions{IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           37,
+						Text:          "INSERT INTO archive SELECT * FROM live",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"archive", "live"},
+						TableRefs: []TableRef{
+							{Name: "archive", Access: AccessWrite},
+							{Name: "live", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should merge reads and writes of the same table",
+				query:   "UPDATE t SET total = o.total FROM t JOIN orders o ON o.id = t.id",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           63,
+						Text:          "UPDATE t SET total = o.total FROM t JOIN orders o ON o.id = t.id",
+						Type:          StatementUpdate,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t", "orders"},
+						TableRefs: []TableRef{
+							{Name: "t", Access: AccessReadWrite},
+							{Name: "orders", Alias: "o", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of CTE bodies and exclude the CTE names",
+				query:   "WITH recent AS (SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id), totals (n) AS (SELECT count(*) FROM recent) SELECT * FROM recent, totals",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           153,
+						Text:          "WITH recent AS (SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id), totals (n) AS (SELECT count(*) FROM recent) SELECT * FROM recent, totals",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"orders", "customers"},
+						TableRefs: []TableRef{
+							{Name: "orders", Alias: "o", Access: AccessRead},
+							{Name: "customers", Alias: "c", Access: AccessRead},
+						},
+						CTEs: []string{"recent", "totals"},
+					},
+				},
+			},
+			{
+				name:    "should exclude a recursive CTE from its own body",
+				query:   "WITH RECURSIVE \"Tree\" AS (SELECT * FROM nodes WHERE parent IS NULL UNION ALL SELECT n.* FROM nodes n JOIN \"Tree\" t ON n.parent = t.id) SELECT * FROM \"Tree\"",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           154,
+						Text:          "WITH RECURSIVE \"Tree\" AS (SELECT * FROM nodes WHERE parent IS NULL UNION ALL SELECT n.* FROM nodes n JOIN \"Tree\" t ON n.parent = t.id) SELECT * FROM \"Tree\"",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"nodes"},
+						TableRefs: []TableRef{
+							{Name: "nodes", Access: AccessRead},
+							{Name: "nodes", Alias: "n", Access: AccessRead},
+						},
+						CTEs: []string{"Tree"},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of subqueries and derived tables",
+				query:   "SELECT * FROM (SELECT * FROM a JOIN b ON a.id = b.id) x WHERE x.id IN (SELECT id FROM c)",
+				options: IdentifyOptions{IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           87,
+						Text:          "SELECT * FROM (SELECT * FROM a JOIN b ON a.id = b.id) x WHERE x.id IN (SELECT id FROM c)",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"a", "b", "c"},
+						TableRefs: []TableRef{
+							{Name: "a", Access: AccessRead},
+							{Name: "b", Access: AccessRead},
+							{Name: "c", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should read clickhouse clause words as table names in other dialects",
+				query:   "SELECT * FROM format f JOIN settings s ON f.id = s.id",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           52,
+						Text:          "SELECT * FROM format f JOIN settings s ON f.id = s.id",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"format", "settings"},
+						TableRefs:     []TableRef{{Name: "format", Alias: "f", Access: AccessRead}, {Name: "settings", Alias: "s", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should read a clause word straight after FROM as a table name",
+				query:   "DELETE FROM output WHERE id = 1",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           30,
+						Text:          "DELETE FROM output WHERE id = 1",
+						Type:          StatementDelete,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"output"},
+						TableRefs:     []TableRef{{Name: "output", Access: AccessWrite}},
+					},
+				},
+			},
+			{
+				name:    "should not read clickhouse clause words as aliases",
+				query:   "SELECT * FROM t FINAL SETTINGS max_threads = 8",
+				options: IdentifyOptions{Dialect: dialect(DialectClickHouse), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           45,
+						Text:          "SELECT * FROM t FINAL SETTINGS max_threads = 8",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should identify the joined tables of a MySQL multi-table UPDATE as written",
+				query:   "UPDATE a JOIN b ON a.id = b.id SET b.x = 1",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           41,
+						Text:          "UPDATE a JOIN b ON a.id = b.id SET b.x = 1",
+						Type:          StatementUpdate,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"a", "b"},
+						TableRefs:     []TableRef{{Name: "a", Access: AccessWrite}, {Name: "b", Access: AccessWrite}},
+					},
+				},
+			},
+		}
+
+		for _, tc := range tableTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify data-modifying CTEs", func(t *testing.T) {
+		cteTestCases := []identifyTestCase{
+			{
+				name:    "should mark a SELECT with a DELETE CTE as a modification",
+				query:   "WITH moved AS (DELETE FROM queue RETURNING *) SELECT * FROM moved",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           64,
+						Text:          "WITH moved AS (DELETE FROM queue RETURNING *) SELECT * FROM moved",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+						ModifyingCTEs: []string{"moved"},
+					},
+				},
+			},
+			{
+				name:    "should list every data-modifying CTE",
+				query:   "WITH a AS (SELECT 1), b AS (UPDATE t SET x = 1 RETURNING *), c AS (INSERT INTO log SELECT * FROM b RETURNING id) SELECT * FROM c",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           127,
+						Text:          "WITH a AS (SELECT 1), b AS (UPDATE t SET x = 1 RETURNING *), c AS (INSERT INTO log SELECT * FROM b RETURNING id) SELECT * FROM c",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+						ModifyingCTEs: []string{"b", "c"},
+					},
+				},
+			},
+			{
+				name:    "should not mark a read-only CTE as a modification",
+				query:   "WITH a AS (SELECT * FROM t) SELECT * FROM a",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           42,
+						Text:          "WITH a AS (SELECT * FROM t) SELECT * FROM a",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range cteTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify MERGE and upsert statements", func(t *testing.T) {
+		upsertTestCases := []identifyTestCase{
+			{
+				name:    "should identify MERGE and the tables it writes and reads",
+				query:   "MERGE INTO dbo.target AS t USING staging AS s ON t.id = s.id WHEN MATCHED THEN UPDATE SET t.v = s.v WHEN NOT MATCHED THEN INSERT (id, v) VALUES (s.id, s.v);",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           155,
+						Text:          "MERGE INTO dbo.target AS t USING staging AS s ON t.id = s.id WHEN MATCHED THEN UPDATE SET t.v = s.v WHEN NOT MATCHED THEN INSERT (id, v) VALUES (s.id, s.v);",
+						Type:          StatementMerge,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"dbo.target", "staging"},
+						TableRefs: []TableRef{
+							{Schema: "dbo", Name: "target", Alias: "t", Access: AccessWrite},
+							{Name: "staging", Alias: "s", Access: AccessRead},
+						},
+					},
+				},
+			},
+			{
+				name:    "should identify MERGE for oracle",
+				query:   "MERGE INTO target t USING source s ON (t.id = s.id) WHEN MATCHED THEN UPDATE SET t.v = s.v",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           89,
+						Text:          "MERGE INTO target t USING source s ON (t.id = s.id) WHEN MATCHED THEN UPDATE SET t.v = s.v",
+						Type:          StatementMerge,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify REPLACE and INSERT OR REPLACE for sqlite",
+				query:   "INSERT OR REPLACE INTO kv (k, v) VALUES ('a', 1);\nINSERT OR IGNORE INTO kv (k, v) VALUES ('a', 1);\nREPLACE INTO kv VALUES ('b', 2);",
+				options: IdentifyOptions{Dialect: dialect(DialectSQLite)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           48,
+						Text:          "INSERT OR REPLACE INTO kv (k, v) VALUES ('a', 1);",
+						Type:          StatementReplace,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         50,
+						End:           97,
+						Text:          "INSERT OR IGNORE INTO kv (k, v) VALUES ('a', 1);",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         99,
+						End:           130,
+						Text:          "REPLACE INTO kv VALUES ('b', 2);",
+						Type:          StatementReplace,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify REPLACE and flag ON DUPLICATE KEY UPDATE for mysql",
+				query:   "REPLACE INTO kv (k, v) VALUES ('a', 1);\nINSERT INTO kv (k, v) VALUES ('a', 1) ON DUPLICATE KEY UPDATE v = v + 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           38,
+						Text:          "REPLACE INTO kv (k, v) VALUES ('a', 1);",
+						Type:          StatementReplace,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         40,
+						End:           111,
+						Text:          "INSERT INTO kv (k, v) VALUES ('a', 1) ON DUPLICATE KEY UPDATE v = v + 1;",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+						Upsert:        true,
+					},
+				},
+			},
+			{
+				name:    "should flag ON CONFLICT for psql",
+				query:   "INSERT INTO kv (k, v) VALUES ('a', 1) ON CONFLICT (k) DO UPDATE SET v = excluded.v;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           82,
+						Text:          "INSERT INTO kv (k, v) VALUES ('a', 1) ON CONFLICT (k) DO UPDATE SET v = excluded.v;",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+						Upsert:        true,
+					},
+				},
+			},
+		}
+
+		for _, tc := range upsertTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+
+		t.Run("should throw error for MERGE in mysql", func(t *testing.T) {
+			assertIdentifyResults(t, "MERGE INTO t USING s ON t.id = s.id", IdentifyOptions{Dialect: dialect(DialectMySQL)}, nil, `Invalid statement parser "MERGE"`)
+		})
+	})
+
+	t.Run("identify mssql batches", func(t *testing.T) {
+		batchTestCases := []identifyTestCase{
+			{
+				name:    "should end statements and batches on GO lines",
+				query:   "CREATE PROCEDURE p AS\nBEGIN\n  SELECT 1\nEND\nGO\nSELECT * FROM a;\nSELECT * FROM b\nGO 5\nDROP PROCEDURE p\n",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           41,
+						Text:          "CREATE PROCEDURE p AS\nBEGIN\n  SELECT 1\nEND",
+						Type:          StatementCreateProcedure,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         46,
+						End:           61,
+						Text:          "SELECT * FROM a;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+						Batch:         1,
+					},
+					{
+						Start:         63,
+						End:           77,
+						Text:          "SELECT * FROM b",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+						Batch:         1,
+					},
+					{
+						Start:         84,
+						End:           100,
+						Text:          "DROP PROCEDURE p\n",
+						Type:          StatementDropProcedure,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+						Batch:         2,
+					},
+				},
+			},
+			{
+				name:    "should end a CTE without a statement on a GO line",
+				query:   "WITH x AS (SELECT 1 AS a)\nGO\nSELECT * FROM x;",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           24,
+						Text:          "WITH x AS (SELECT 1 AS a)",
+						Type:          StatementUnknown,
+						ExecutionType: ExecutionUnknown,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         29,
+						End:           44,
+						Text:          "SELECT * FROM x;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"x"},
+						TableRefs:     []TableRef{{Name: "x", Access: AccessRead}},
+						Batch:         1,
+					},
+				},
+			},
+		}
+
+		for _, tc := range batchTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+
+		t.Run("should group statements by batch with their repeat counts", func(t *testing.T) {
+			query := "SELECT 1;\nSELECT 2\nGO\nINSERT INTO t VALUES (1)\nGO 3\n"
+			batches, err := IdentifyBatches(query, IdentifyOptions{Dialect: dialect(DialectMSSQL)})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			expected := []struct {
+				repeat int
+				texts  []string
+			}{
+				{repeat: 1, texts: []string{"SELECT 1;", "SELECT 2"}},
+				{repeat: 3, texts: []string{"INSERT INTO t VALUES (1)"}},
+			}
+			if len(batches) != len(expected) {
+				t.Fatalf("Expected %d batches, got %d: %+v", len(expected), len(batches), batches)
+			}
+			for i, batch := range batches {
+				var texts []string
+				for _, statement := range batch.Statements {
+					texts = append(texts, statement.Text)
+				}
+				if batch.Index != i || batch.Repeat != expected[i].repeat || !reflect.DeepEqual(texts, expected[i].texts) {
+					t.Errorf("Expected batch %d to repeat %d times with %q, got %+v", i, expected[i].repeat, expected[i].texts, batch)
+				}
+			}
+		})
+	})
+
+	t.Run("identify mysql DELIMITER scripts", func(t *testing.T) {
+		delimiterTestCases := []identifyTestCase{
+			{
+				name:    "should split statements on the delimiters set by DELIMITER lines",
+				query:   "DROP PROCEDURE IF EXISTS p;\nDELIMITER $$\nCREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND$$\nCREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW SET NEW.a = 1; $$\nDELIMITER ;\nSELECT 3;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           26,
+						Text:          "DROP PROCEDURE IF EXISTS p;",
+						Type:          StatementDropProcedure,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         41,
+						End:           94,
+						Text:          "CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND",
+						Type:          StatementCreateProcedure,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         98,
+						End:           160,
+						Text:          "CREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW SET NEW.a = 1;",
+						Type:          StatementCreateTrigger,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         177,
+						End:           185,
+						Text:          "SELECT 3;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should support multi-character delimiters",
+				query:   "DELIMITER ;;\nSELECT 1;; SELECT 2;;\ndelimiter //\nSELECT 3//",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         13,
+						End:           20,
+						Text:          "SELECT 1",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         24,
+						End:           31,
+						Text:          "SELECT 2",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         48,
+						End:           55,
+						Text:          "SELECT 3",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should end a CTE without a statement on a DELIMITER line",
+				query:   "WITH x AS (SELECT 1)\nDELIMITER $$\nSELECT * FROM x$$",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           19,
+						Text:          "WITH x AS (SELECT 1)",
+						Type:          StatementUnknown,
+						ExecutionType: ExecutionUnknown,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         34,
+						End:           48,
+						Text:          "SELECT * FROM x",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"x"},
+						TableRefs:     []TableRef{{Name: "x", Access: AccessRead}},
+					},
+				},
+			},
+		}
+
+		for _, tc := range delimiterTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify oracle scripts", func(t *testing.T) {
+		oracleTestCases := []identifyTestCase{
+			{
+				name:    "should end package bodies at a slash line and report SQL*Plus commands",
+				query:   "SET SERVEROUTPUT ON\nCREATE OR REPLACE PACKAGE BODY pkg AS\n  PROCEDURE p IS\n  BEGIN\n    NULL;\n  END p;\nEND pkg;\n/\n@script.sql\nSELECT 1 / 2 FROM dual;",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           18,
+						Text:          "SET SERVEROUTPUT ON",
+						Type:          StatementClientCommand,
+						ExecutionType: ExecutionClientCommand,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         20,
+						End:           109,
+						Text:          "CREATE OR REPLACE PACKAGE BODY pkg AS\n  PROCEDURE p IS\n  BEGIN\n    NULL;\n  END p;\nEND pkg;",
+						Type:          StatementCreatePackageBody,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         113,
+						End:           123,
+						Text:          "@script.sql",
+						Type:          StatementClientCommand,
+						ExecutionType: ExecutionClientCommand,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         125,
+						End:           147,
+						Text:          "SELECT 1 / 2 FROM dual;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify packages and types terminated by slash lines",
+				query:   "CREATE PACKAGE pkg AS\n  FUNCTION f RETURN NUMBER;\nEND pkg;\n/\nCREATE TYPE pt AS OBJECT (x NUMBER);\n/\nBEGIN\n  NULL;\nEND;\n/",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           57,
+						Text:          "CREATE PACKAGE pkg AS\n  FUNCTION f RETURN NUMBER;\nEND pkg;",
+						Type:          StatementCreatePackage,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         61,
+						End:           96,
+						Text:          "CREATE TYPE pt AS OBJECT (x NUMBER);",
+						Type:          StatementCreateType,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         100,
+						End:           117,
+						Text:          "BEGIN\n  NULL;\nEND;",
+						Type:          StatementAnonBlock,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should keep SET TRANSACTION as SQL",
+				query:   "SET TRANSACTION READ ONLY;",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle), Strict: strict(false)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           25,
+						Text:          "SET TRANSACTION READ ONLY;",
+						Type:          StatementUnknown,
+						ExecutionType: ExecutionUnknown,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify SQL*Plus EXEC calls as anonymous blocks",
+				query:   "EXEC drop_everything;\nEXECUTE dbms_output.put_line('x');",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           20,
+						Text:          "EXEC drop_everything;",
+						Type:          StatementAnonBlock,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         22,
+						End:           55,
+						Text:          "EXECUTE dbms_output.put_line('x');",
+						Type:          StatementAnonBlock,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should end an EXEC call with its line unless it is continued",
+				query:   "EXEC p(1, -\n  2)\nSELECT 1 FROM dual;",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           15,
+						Text:          "EXEC p(1, -\n  2)",
+						Type:          StatementAnonBlock,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         17,
+						End:           35,
+						Text:          "SELECT 1 FROM dual;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range oracleTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify psql scripts", func(t *testing.T) {
+		psqlTestCases := []identifyTestCase{
+			{
+				name:    "should report meta-commands and keep COPY data with its statement",
+				query:   "\\connect mydb\nCOPY public.t (a, b) FROM stdin;\n1\tfoo\n2\tbar; DROP TABLE x;\n\\.\n\\set ON_ERROR_STOP on\nSELECT 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           12,
+						Text:          "\\connect mydb",
+						Type:          StatementClientCommand,
+						ExecutionType: ExecutionClientCommand,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         14,
+						End:           45,
+						Text:          "COPY public.t (a, b) FROM stdin;",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+						CopyData:      "1\tfoo\n2\tbar; DROP TABLE x;\n",
+					},
+					{
+						Start:         77,
+						End:           97,
+						Text:          "\\set ON_ERROR_STOP on",
+						Type:          StatementClientCommand,
+						ExecutionType: ExecutionClientCommand,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         99,
+						End:           107,
+						Text:          "SELECT 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify COPY TO STDOUT as listing and COPY FROM a file as modification",
+				query:   "COPY t TO STDOUT;\nCOPY t FROM '/tmp/t.csv';",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           16,
+						Text:          "COPY t TO STDOUT;",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         18,
+						End:           42,
+						Text:          "COPY t FROM '/tmp/t.csv';",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of COPY statements",
+				query:   "COPY (SELECT * FROM a JOIN b ON a.id = b.id) TO STDOUT;\nCOPY public.t FROM stdin;\n\\.",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           54,
+						Text:          "COPY (SELECT * FROM a JOIN b ON a.id = b.id) TO STDOUT;",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"a", "b"},
+						TableRefs:     []TableRef{{Name: "a", Access: AccessRead}, {Name: "b", Access: AccessRead}},
+					},
+					{
+						Start:         56,
+						End:           80,
+						Text:          "COPY public.t FROM stdin;",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"public.t"},
+						TableRefs:     []TableRef{{Schema: "public", Name: "t", Access: AccessWrite}},
+					},
+				},
+			},
+			{
+				name:    "should only identify COPY TO STDOUT as listing",
+				query:   "COPY t TO STDOUT;\nCOPY t TO PROGRAM 'rm -rf /';\nCOPY t TO '/etc/passwd';",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           16,
+						Text:          "COPY t TO STDOUT;",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+					{
+						Start:         18,
+						End:           46,
+						Text:          "COPY t TO PROGRAM 'rm -rf /';",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+					{
+						Start:         48,
+						End:           71,
+						Text:          "COPY t TO '/etc/passwd';",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should identify psql ALTER SEQUENCE, ALTER TYPE and ALTER DOMAIN",
+				query:   "ALTER SEQUENCE s RESTART WITH 1;\nALTER TYPE mood ADD VALUE 'meh';\nALTER DOMAIN posint SET NOT NULL;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           31,
+						Text:          "ALTER SEQUENCE s RESTART WITH 1;",
+						Type:          StatementAlterSequence,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         33,
+						End:           64,
+						Text:          "ALTER TYPE mood ADD VALUE 'meh';",
+						Type:          StatementAlterType,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         66,
+						End:           98,
+						Text:          "ALTER DOMAIN posint SET NOT NULL;",
+						Type:          StatementAlterDomain,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range psqlTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify snowflake statements", func(t *testing.T) {
+		snowflakeTestCases := []identifyTestCase{
+			{
+				name:    "should identify warehouse and stage statements",
+				query:   "USE WAREHOUSE wh;\nCREATE OR REPLACE STAGE my_stage URL = 's3://b/p';\nCREATE PIPE p AS COPY INTO t FROM @my_stage;\nCREATE STREAM s ON TABLE t;\nCREATE TASK tk SCHEDULE = '5 MINUTE' AS INSERT INTO t SELECT 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectSnowflake)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           16,
+						Text:          "USE WAREHOUSE wh;",
+						Type:          StatementUseWarehouse,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         18,
+						End:           67,
+						Text:          "CREATE OR REPLACE STAGE my_stage URL = 's3://b/p';",
+						Type:          StatementCreateStage,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         69,
+						End:           112,
+						Text:          "CREATE PIPE p AS COPY INTO t FROM @my_stage;",
+						Type:          StatementCreatePipe,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         114,
+						End:           140,
+						Text:          "CREATE STREAM s ON TABLE t;",
+						Type:          StatementCreateStream,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         142,
+						End:           204,
+						Text:          "CREATE TASK tk SCHEDULE = '5 MINUTE' AS INSERT INTO t SELECT 1;",
+						Type:          StatementCreateTask,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify loading and unloading statements",
+				query:   "COPY INTO db.sch.t FROM @my_stage;\nCOPY INTO @my_stage/out FROM t;\nPUT file:///tmp/data.csv @my_stage;\nGET @my_stage file:///tmp/;",
+				options: IdentifyOptions{Dialect: dialect(DialectSnowflake), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           33,
+						Text:          "COPY INTO db.sch.t FROM @my_stage;",
+						Type:          StatementCopyInto,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"db.sch.t"},
+						TableRefs:     []TableRef{{Catalog: "db", Schema: "sch", Name: "t", Access: AccessWrite}},
+					},
+					{
+						Start:         35,
+						End:           65,
+						Text:          "COPY INTO @my_stage/out FROM t;",
+						Type:          StatementCopyInto,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+					{
+						Start:         67,
+						End:           101,
+						Text:          "PUT file:///tmp/data.csv @my_stage;",
+						Type:          StatementPut,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         103,
+						End:           129,
+						Text:          "GET @my_stage file:///tmp/;",
+						Type:          StatementGet,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify dollar-quoted bodies and scripting blocks",
+				query:   "CREATE PROCEDURE p() RETURNS VARCHAR LANGUAGE SQL AS $$ BEGIN RETURN 'x'; END; $$;\nEXECUTE IMMEDIATE $$ SELECT 1; $$;\nDECLARE x INT; BEGIN IF (x > 0) THEN RETURN 1; END IF; END;\nBEGIN;",
+				options: IdentifyOptions{Dialect: dialect(DialectSnowflake)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           81,
+						Text:          "CREATE PROCEDURE p() RETURNS VARCHAR LANGUAGE SQL AS $$ BEGIN RETURN 'x'; END; $$;",
+						Type:          StatementCreateProcedure,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         83,
+						End:           116,
+						Text:          "EXECUTE IMMEDIATE $$ SELECT 1; $$;",
+						Type:          StatementExecuteImmediate,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         118,
+						End:           176,
+						Text:          "DECLARE x INT; BEGIN IF (x > 0) THEN RETURN 1; END IF; END;",
+						Type:          StatementAnonBlock,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         178,
+						End:           183,
+						Text:          "BEGIN;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify snowflake parameters",
+				query:   "SELECT * FROM t WHERE a = :name AND b = :1 AND c = ?",
+				options: IdentifyOptions{Dialect: dialect(DialectSnowflake)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           51,
+						Text:          "SELECT * FROM t WHERE a = :name AND b = :1 AND c = ?",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{":name", ":1", "?"},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify unloading into an external location as a modification",
+				query:   "COPY INTO 's3://bucket/out/' FROM (SELECT * FROM t) STORAGE_INTEGRATION = s3_int;",
+				options: IdentifyOptions{Dialect: dialect(DialectSnowflake), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           80,
+						Text:          "COPY INTO 's3://bucket/out/' FROM (SELECT * FROM t) STORAGE_INTEGRATION = s3_int;",
+						Type:          StatementCopyInto,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should identify snowflake USE statements",
+				query:   "USE WAREHOUSE w;\nUSE DATABASE d;\nUSE SCHEMA d.s;\nUSE ROLE r;\nUSE SECONDARY ROLES ALL;",
+				options: IdentifyOptions{Dialect: dialect(DialectSnowflake)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           15,
+						Text:          "USE WAREHOUSE w;",
+						Type:          StatementUseWarehouse,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         17,
+						End:           31,
+						Text:          "USE DATABASE d;",
+						Type:          StatementUseDatabase,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         33,
+						End:           47,
+						Text:          "USE SCHEMA d.s;",
+						Type:          StatementUseSchema,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         49,
+						End:           59,
+						Text:          "USE ROLE r;",
+						Type:          StatementUseRole,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         61,
+						End:           84,
+						Text:          "USE SECONDARY ROLES ALL;",
+						Type:          StatementUseSecondaryRoles,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range snowflakeTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify clickhouse statements", func(t *testing.T) {
+		clickhouseTestCases := []identifyTestCase{
+			{
+				name:    "should identify typed parameters and tables before FORMAT and SETTINGS",
+				query:   "SELECT * FROM t FINAL WHERE id = {id:UInt32} AND name IN {names:Array(String)} SETTINGS max_threads = 8 FORMAT JSONEachRow;",
+				options: IdentifyOptions{Dialect: dialect(DialectClickHouse), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           122,
+						Text:          "SELECT * FROM t FINAL WHERE id = {id:UInt32} AND name IN {names:Array(String)} SETTINGS max_threads = 8 FORMAT JSONEachRow;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{"{id:UInt32}", "{names:Array(String)}"},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should identify clickhouse statements",
+				query:   "OPTIMIZE TABLE t FINAL;\nSYSTEM RELOAD DICTIONARIES;\nATTACH TABLE t;\nDETACH TABLE t;\nEXISTS TABLE t;\nDESC t;\nSHOW DICTIONARIES;",
+				options: IdentifyOptions{Dialect: dialect(DialectClickHouse)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           22,
+						Text:          "OPTIMIZE TABLE t FINAL;",
+						Type:          StatementOptimize,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         24,
+						End:           50,
+						Text:          "SYSTEM RELOAD DICTIONARIES;",
+						Type:          StatementSystem,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         52,
+						End:           66,
+						Text:          "ATTACH TABLE t;",
+						Type:          StatementAttach,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         68,
+						End:           82,
+						Text:          "DETACH TABLE t;",
+						Type:          StatementDetach,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         84,
+						End:           98,
+						Text:          "EXISTS TABLE t;",
+						Type:          StatementExists,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         100,
+						End:           106,
+						Text:          "DESC t;",
+						Type:          StatementDescribe,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         108,
+						End:           125,
+						Text:          "SHOW DICTIONARIES;",
+						Type:          StatementShowDictionaries,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify mutations as writes to the altered table",
+				query:   "ALTER TABLE t UPDATE x = 1 WHERE y = 2;\nALTER TABLE t DELETE WHERE y = 2;\nALTER TABLE t ADD COLUMN z Int32;",
+				options: IdentifyOptions{Dialect: dialect(DialectClickHouse), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           38,
+						Text:          "ALTER TABLE t UPDATE x = 1 WHERE y = 2;",
+						Type:          StatementAlterTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessWrite}},
+					},
+					{
+						Start:         40,
+						End:           72,
+						Text:          "ALTER TABLE t DELETE WHERE y = 2;",
+						Type:          StatementAlterTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessWrite}},
+					},
+					{
+						Start:         74,
+						End:           106,
+						Text:          "ALTER TABLE t ADD COLUMN z Int32;",
+						Type:          StatementAlterTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessDDL}},
+					},
+				},
+			},
+			{
+				name:    "should end statements at semicolons as clickhouse has no blocks",
+				query:   "CREATE FUNCTION f AS (x) -> x + 1;\nSELECT 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectClickHouse)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           33,
+						Text:          "CREATE FUNCTION f AS (x) -> x + 1;",
+						Type:          StatementCreateFunction,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         35,
+						End:           43,
+						Text:          "SELECT 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range clickhouseTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify redshift and cockroachdb statements", func(t *testing.T) {
+		psqlFamilyTestCases := []identifyTestCase{
+			{
+				name:    "should identify redshift statements",
+				query:   "UNLOAD ('select * from t') TO 's3://b/p' IAM_ROLE 'arn';\nCOPY t FROM 's3://b/p' IAM_ROLE 'arn' CSV;\nVACUUM FULL t;\nCREATE EXTERNAL SCHEMA s FROM DATA CATALOG DATABASE 'db';\nCREATE EXTERNAL TABLE s.t (a int) LOCATION 's3://x';",
+				options: IdentifyOptions{Dialect: dialect(DialectRedshift)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           55,
+						Text:          "UNLOAD ('select * from t') TO 's3://b/p' IAM_ROLE 'arn';",
+						Type:          StatementUnload,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         57,
+						End:           98,
+						Text:          "COPY t FROM 's3://b/p' IAM_ROLE 'arn' CSV;",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         100,
+						End:           113,
+						Text:          "VACUUM FULL t;",
+						Type:          StatementVacuum,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         115,
+						End:           171,
+						Text:          "CREATE EXTERNAL SCHEMA s FROM DATA CATALOG DATABASE 'db';",
+						Type:          StatementCreateExternalSchema,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         173,
+						End:           224,
+						Text:          "CREATE EXTERNAL TABLE s.t (a int) LOCATION 's3://x';",
+						Type:          StatementCreateExternalTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should follow the psql rules",
+				query:   "SELECT * FROM t WHERE a = $2 AND b = $1;\nCREATE FUNCTION f() RETURNS int AS $$ SELECT 1 $$ LANGUAGE sql;\nBEGIN;",
+				options: IdentifyOptions{Dialect: dialect(DialectRedshift)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           39,
+						Text:          "SELECT * FROM t WHERE a = $2 AND b = $1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{"$1", "$2"},
+						Tables:        []string{},
+					},
+					{
+						Start:         41,
+						End:           103,
+						Text:          "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1 $$ LANGUAGE sql;",
+						Type:          StatementCreateFunction,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         105,
+						End:           110,
+						Text:          "BEGIN;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify cockroachdb statements",
+				query:   "IMPORT INTO t (a, b) CSV DATA ('s3://x');\nBACKUP TABLE t INTO 's3://b';\nRESTORE TABLE t FROM LATEST IN 's3://b';\nSHOW RANGES FROM TABLE t;\nVACUUM t;",
+				options: IdentifyOptions{Dialect: dialect(DialectCockroachDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           40,
+						Text:          "IMPORT INTO t (a, b) CSV DATA ('s3://x');",
+						Type:          StatementImport,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         42,
+						End:           70,
+						Text:          "BACKUP TABLE t INTO 's3://b';",
+						Type:          StatementBackup,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         72,
+						End:           111,
+						Text:          "RESTORE TABLE t FROM LATEST IN 's3://b';",
+						Type:          StatementRestore,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         113,
+						End:           137,
+						Text:          "SHOW RANGES FROM TABLE t;",
+						Type:          StatementShowRanges,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         139,
+						End:           147,
+						Text:          "VACUUM t;",
+						Type:          StatementVacuum,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should not read AS OF SYSTEM TIME as an alias",
+				query:   "SELECT * FROM t AS OF SYSTEM TIME '-10s'",
+				options: IdentifyOptions{Dialect: dialect(DialectCockroachDB), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           39,
+						Text:          "SELECT * FROM t AS OF SYSTEM TIME '-10s'",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should identify redshift external functions",
+				query:   "CREATE EXTERNAL FUNCTION f(int) RETURNS int VOLATILE LAMBDA 'fn' IAM_ROLE 'arn';",
+				options: IdentifyOptions{Dialect: dialect(DialectRedshift)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           79,
+						Text:          "CREATE EXTERNAL FUNCTION f(int) RETURNS int VOLATILE LAMBDA 'fn' IAM_ROLE 'arn';",
+						Type:          StatementCreateExternalFunction,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:          "should reject other external objects in redshift",
+				query:         "CREATE EXTERNAL banana x;",
+				options:       IdentifyOptions{Dialect: dialect(DialectRedshift)},
+				expectedError: `instead of type="identifier" value="banana" (currentStep=2)`,
+			},
+			{
+				name:    "should identify other external objects as unknown in non-strict redshift",
+				query:   "CREATE EXTERNAL FOO x;",
+				options: IdentifyOptions{Dialect: dialect(DialectRedshift), Strict: strict(false)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           21,
+						Text:          "CREATE EXTERNAL FOO x;",
+						Type:          StatementUnknown,
+						ExecutionType: ExecutionUnknown,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+
+		for _, tc := range psqlFamilyTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify duckdb and sparksql statements", func(t *testing.T) {
+		duckdbSparkTestCases := []identifyTestCase{
+			{
+				name:    "should identify duckdb statements",
+				query:   "SELECT * FROM t WHERE a = $1 AND b = $name;\nFROM t WHERE a > 1;\nATTACH 'file.db' AS f;\nCOPY t TO 'out.parquet' (FORMAT PARQUET);\nEXPORT DATABASE 'dir';\nIMPORT DATABASE 'dir';\nINSTALL httpfs;\nLOAD httpfs;\nPIVOT cities ON year USING sum(population);",
+				options: IdentifyOptions{Dialect: dialect(DialectDuckDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           42,
+						Text:          "SELECT * FROM t WHERE a = $1 AND b = $name;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{"$1", "$name"},
+						Tables:        []string{},
+					},
+					{
+						Start:         44,
+						End:           62,
+						Text:          "FROM t WHERE a > 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         64,
+						End:           85,
+						Text:          "ATTACH 'file.db' AS f;",
+						Type:          StatementAttach,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         87,
+						End:           127,
+						Text:          "COPY t TO 'out.parquet' (FORMAT PARQUET);",
+						Type:          StatementCopy,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         129,
+						End:           150,
+						Text:          "EXPORT DATABASE 'dir';",
+						Type:          StatementExportDatabase,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         152,
+						End:           173,
+						Text:          "IMPORT DATABASE 'dir';",
+						Type:          StatementImportDatabase,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         175,
+						End:           189,
+						Text:          "INSTALL httpfs;",
+						Type:          StatementInstall,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         191,
+						End:           202,
+						Text:          "LOAD httpfs;",
+						Type:          StatementLoad,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         204,
+						End:           246,
+						Text:          "PIVOT cities ON year USING sum(population);",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should read the tables of a FROM-first query",
+				query:   "FROM t SELECT a",
+				options: IdentifyOptions{Dialect: dialect(DialectDuckDB), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           14,
+						Text:          "FROM t SELECT a",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{"t"},
+						TableRefs:     []TableRef{{Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+			{
+				name:    "should identify sparksql statements",
+				query:   "SELECT * FROM t WHERE a = ${var};\nCACHE TABLE t;\nMSCK REPAIR TABLE t;\nOPTIMIZE t;\nVACUUM t;\nCREATE TABLE t (a INT) USING delta;",
+				options: IdentifyOptions{Dialect: dialect(DialectSparkSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           32,
+						Text:          "SELECT * FROM t WHERE a = ${var};",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{"${var}"},
+						Tables:        []string{},
+					},
+					{
+						Start:         34,
+						End:           47,
+						Text:          "CACHE TABLE t;",
+						Type:          StatementCacheTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         49,
+						End:           68,
+						Text:          "MSCK REPAIR TABLE t;",
+						Type:          StatementMsckRepair,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         70,
+						End:           80,
+						Text:          "OPTIMIZE t;",
+						Type:          StatementOptimize,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         82,
+						End:           90,
+						Text:          "VACUUM t;",
+						Type:          StatementVacuum,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         92,
+						End:           126,
+						Text:          "CREATE TABLE t (a INT) USING delta;",
+						Type:          StatementCreateTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should read the target of INSERT OVERWRITE",
+				query:   "INSERT OVERWRITE TABLE `db`.t SELECT * FROM s",
+				options: IdentifyOptions{Dialect: dialect(DialectSparkSQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           44,
+						Text:          "INSERT OVERWRITE TABLE `db`.t SELECT * FROM s",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"`db`.t", "s"},
+						TableRefs:     []TableRef{{Schema: "db", Name: "t", SchemaQuoted: true, Access: AccessWrite}, {Name: "s", Access: AccessRead}},
+					},
+				},
+			},
+		}
+		for _, tc := range duckdbSparkTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify mariadb statements", func(t *testing.T) {
+		mariadbTestCases := []identifyTestCase{
+			{
+				name:    "should identify mariadb statements",
+				query:   "CREATE OR REPLACE TABLE t (a int);\nINSERT INTO t VALUES (1) RETURNING a;\nDELETE FROM t WHERE a = 1 RETURNING a;\nCREATE SEQUENCE s START WITH 1;\nSELECT NEXTVAL(s);\nDROP SEQUENCE s;\nSHOW EXPLAIN FOR 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectMariaDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           33,
+						Text:          "CREATE OR REPLACE TABLE t (a int);",
+						Type:          StatementCreateTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         35,
+						End:           71,
+						Text:          "INSERT INTO t VALUES (1) RETURNING a;",
+						Type:          StatementInsert,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         73,
+						End:           110,
+						Text:          "DELETE FROM t WHERE a = 1 RETURNING a;",
+						Type:          StatementDelete,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         112,
+						End:           142,
+						Text:          "CREATE SEQUENCE s START WITH 1;",
+						Type:          StatementCreateSequence,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         144,
+						End:           161,
+						Text:          "SELECT NEXTVAL(s);",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         163,
+						End:           178,
+						Text:          "DROP SEQUENCE s;",
+						Type:          StatementDropSequence,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         180,
+						End:           198,
+						Text:          "SHOW EXPLAIN FOR 1;",
+						Type:          StatementShowExplain,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify BEGIN NOT ATOMIC blocks",
+				query:   "BEGIN NOT ATOMIC DECLARE x INT; IF x > 1 THEN SELECT 1; END IF; END;\nBEGIN;",
+				options: IdentifyOptions{Dialect: dialect(DialectMariaDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           67,
+						Text:          "BEGIN NOT ATOMIC DECLARE x INT; IF x > 1 THEN SELECT 1; END IF; END;",
+						Type:          StatementAnonBlock,
+						ExecutionType: ExecutionAnonBlock,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         69,
+						End:           74,
+						Text:          "BEGIN;",
+						Type:          StatementBeginTransaction,
+						ExecutionType: ExecutionTransaction,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should follow the mysql rules",
+				query:   "DELIMITER $$\nCREATE PROCEDURE p() BEGIN SELECT 1; END$$\nDELIMITER ;\nSELECT \"a;b\";",
+				options: IdentifyOptions{Dialect: dialect(DialectMariaDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         13,
+						End:           52,
+						Text:          "CREATE PROCEDURE p() BEGIN SELECT 1; END",
+						Type:          StatementCreateProcedure,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         68,
+						End:           80,
+						Text:          "SELECT \"a;b\";",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify mariadb SET statements",
+				query:   "SET @a = 1;\nSET SESSION sql_mode = '';\nSET PASSWORD = PASSWORD('x');",
+				options: IdentifyOptions{Dialect: dialect(DialectMariaDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           10,
+						Text:          "SET @a = 1;",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         12,
+						End:           37,
+						Text:          "SET SESSION sql_mode = '';",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         39,
+						End:           67,
+						Text:          "SET PASSWORD = PASSWORD('x');",
+						Type:          StatementSetPassword,
+						ExecutionType: ExecutionPermission,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+		for _, tc := range mariadbTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify trino statements", func(t *testing.T) {
+		trinoTestCases := []identifyTestCase{
+			{
+				name:    "should identify trino statements",
+				query:   "PREPARE q FROM SELECT * FROM t WHERE a = ?;\nEXECUTE q USING 1;\nDEALLOCATE PREPARE q;\nCALL system.sync_partition_metadata('s', 't', 'ADD');\nSET SESSION query_max_run_time = '10m';",
+				options: IdentifyOptions{Dialect: dialect(DialectTrino)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           42,
+						Text:          "PREPARE q FROM SELECT * FROM t WHERE a = ?;",
+						Type:          StatementPrepare,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{"?"},
+						Tables:        []string{},
+					},
+					{
+						Start:         44,
+						End:           61,
+						Text:          "EXECUTE q USING 1;",
+						Type:          StatementExecute,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         63,
+						End:           83,
+						Text:          "DEALLOCATE PREPARE q;",
+						Type:          StatementDeallocate,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         85,
+						End:           137,
+						Text:          "CALL system.sync_partition_metadata('s', 't', 'ADD');",
+						Type:          StatementCall,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         139,
+						End:           177,
+						Text:          "SET SESSION query_max_run_time = '10m';",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify trino SHOW statements",
+				query:   "SHOW CATALOGS;\nSHOW SCHEMAS FROM hive;\nSHOW SESSION;\nSHOW STATS FOR hive.s.t;\nSHOW TABLES;",
+				options: IdentifyOptions{Dialect: dialect(DialectTrino)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           13,
+						Text:          "SHOW CATALOGS;",
+						Type:          StatementShowCatalogs,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         15,
+						End:           37,
+						Text:          "SHOW SCHEMAS FROM hive;",
+						Type:          StatementShowSchemas,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         39,
+						End:           51,
+						Text:          "SHOW SESSION;",
+						Type:          StatementShowSession,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         53,
+						End:           76,
+						Text:          "SHOW STATS FOR hive.s.t;",
+						Type:          StatementShowStats,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         78,
+						End:           89,
+						Text:          "SHOW TABLES;",
+						Type:          StatementShowTables,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify three-part table names",
+				query:   "SELECT * FROM \"hive\".\"sales\".\"orders\" o JOIN iceberg.s.t ON o.id = t.id",
+				options: IdentifyOptions{Dialect: dialect(DialectTrino), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           70,
+						Text:          "SELECT * FROM \"hive\".\"sales\".\"orders\" o JOIN iceberg.s.t ON o.id = t.id",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{`"hive"."sales"."orders"`, "iceberg.s.t"},
+						TableRefs:     []TableRef{{Catalog: "hive", Schema: "sales", Name: "orders", Alias: "o", CatalogQuoted: true, SchemaQuoted: true, NameQuoted: true, Access: AccessRead}, {Catalog: "iceberg", Schema: "s", Name: "t", Access: AccessRead}},
+					},
+				},
+			},
+		}
+		for _, tc := range trinoTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify mysql comments", func(t *testing.T) {
+		mysqlCommentTestCases := []identifyTestCase{
+			{
+				name:    "should ignore hash comments",
+				query:   "# comment; DROP TABLE x\nSELECT 1; # trailing; note\nSELECT 2;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         24,
+						End:           32,
+						Text:          "SELECT 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         51,
+						End:           59,
+						Text:          "SELECT 2;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should classify the contents of executable comments",
+				query:   "/*!40000 DROP TABLE x */;\nCREATE DATABASE /*!32312 IF NOT EXISTS*/ `db`;\nSELECT /* DROP TABLE y; */ 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           24,
+						Text:          "/*!40000 DROP TABLE x */;",
+						Type:          StatementDropTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         26,
+						End:           71,
+						Text:          "CREATE DATABASE /*!32312 IF NOT EXISTS*/ `db`;",
+						Type:          StatementCreateDatabase,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         73,
+						End:           101,
+						Text:          "SELECT /* DROP TABLE y; */ 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify the tables of executable comments",
+				query:   "/*!40000 ALTER TABLE `t` DISABLE KEYS */",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL), IdentifyTables: withTables(true)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           39,
+						Text:          "/*!40000 ALTER TABLE `t` DISABLE KEYS */",
+						Type:          StatementAlterTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{"`t`"},
+						TableRefs:     []TableRef{{Name: "t", NameQuoted: true, Access: AccessDDL}},
+					},
+				},
+			},
+			{
+				name:    "should classify mariadb executable comments",
+				query:   "/*M!100100 DROP TABLE y */;",
+				options: IdentifyOptions{Dialect: dialect(DialectMariaDB)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           26,
+						Text:          "/*M!100100 DROP TABLE y */;",
+						Type:          StatementDropTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should identify the SET lines of a mysqldump header",
+				query:   "/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n/*!40101 SET NAMES utf8mb4 */;\n/*!40014 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0 */;\n/*!40103 SET TIME_ZONE='+00:00' */;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           64,
+						Text:          "/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         66,
+						End:           95,
+						Text:          "/*!40101 SET NAMES utf8mb4 */;",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         97,
+						End:           164,
+						Text:          "/*!40014 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0 */;",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         166,
+						End:           200,
+						Text:          "/*!40103 SET TIME_ZONE='+00:00' */;",
+						Type:          StatementSetSession,
+						ExecutionType: ExecutionSession,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+		for _, tc := range mysqlCommentTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify string literals", func(t *testing.T) {
+		stringLiteralTestCases := []identifyTestCase{
+			{
+				name:    "should not split mysql strings on escaped quotes",
+				query:   "SELECT 'it\\'s; here';\nDROP TABLE t;",
+				options: IdentifyOptions{Dialect: dialect(DialectMySQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           20,
+						Text:          "SELECT 'it\\'s; here';",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         22,
+						End:           34,
+						Text:          "DROP TABLE t;",
+						Type:          StatementDropTable,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should not split psql escape strings",
+				query:   "SELECT E'it\\'s; here';\nSELECT 'a\\';",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           21,
+						Text:          "SELECT E'it\\'s; here';",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         23,
+						End:           34,
+						Text:          "SELECT 'a\\';",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should not split oracle quote operator strings",
+				query:   "SELECT q'[it's; here]' FROM dual;\nDELETE FROM t;",
+				options: IdentifyOptions{Dialect: dialect(DialectOracle)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           32,
+						Text:          "SELECT q'[it's; here]' FROM dual;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         34,
+						End:           47,
+						Text:          "DELETE FROM t;",
+						Type:          StatementDelete,
+						ExecutionType: ExecutionModification,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should not split bigquery raw and triple quoted strings",
+				query:   "SELECT r'a\\'; b', '''it's; here''';\nSELECT \"\"\"x\"; y\"\"\";",
+				options: IdentifyOptions{Dialect: dialect(DialectBigQuery)},
+				expected: []IdentifyResult{
+					{
+						Start:         0,
+						End:           34,
+						Text:          "SELECT r'a\\'; b', '''it's; here''';",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+					{
+						Start:         36,
+						End:           54,
+						Text:          "SELECT \"\"\"x\"; y\"\"\";",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+		for _, tc := range stringLiteralTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("identify nested comments", func(t *testing.T) {
+		nestedCommentTestCases := []identifyTestCase{
+			{
+				name:    "should ignore the code in nested psql comments",
+				query:   "/* disabled:\n/* drop the old data */\nDROP TABLE t;\n*/\nSELECT 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectPSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         54,
+						End:           62,
+						Text:          "SELECT 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+			{
+				name:    "should ignore the code in nested mssql comments",
+				query:   "/* disabled:\n/* drop the old data */\nDROP TABLE t;\n*/\nSELECT 1;",
+				options: IdentifyOptions{Dialect: dialect(DialectMSSQL)},
+				expected: []IdentifyResult{
+					{
+						Start:         54,
+						End:           62,
+						Text:          "SELECT 1;",
+						Type:          StatementSelect,
+						ExecutionType: ExecutionListing,
+						Parameters:    []string{},
+						Tables:        []string{},
+					},
+				},
+			},
+		}
+		for _, tc := range nestedCommentTestCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assertIdentifyResults(t, tc.query, tc.options, tc.expected, tc.expectedError)
+			})
+		}
+	})
+
+	t.Run("positions", func(t *testing.T) {
+		t.Run("should report byte offsets, lines and columns for multi-byte queries", func(t *testing.T) {
+			query := "SELECT 'café ☕';\nSELECT 'ok';"
+			expected := []IdentifyResult{
+				{
+					Start:         0,
+					End:           15,
+					StartByte:     0,
+					EndByte:       18,
+					StartLine:     1,
+					StartColumn:   1,
+					EndLine:       1,
+					EndColumn:     16,
+					Text:          "SELECT 'café ☕';",
+					Type:          StatementSelect,
+					ExecutionType: ExecutionListing,
+					Parameters:    []string{},
+					Tables:        []string{},
+				},
+				{
+					Start:         17,
+					End:           28,
+					StartByte:     20,
+					EndByte:       31,
+					StartLine:     2,
+					StartColumn:   1,
+					EndLine:       2,
+					EndColumn:     12,
+					Text:          "SELECT 'ok';",
+					Type:          StatementSelect,
+					ExecutionType: ExecutionListing,
+					Parameters:    []string{},
+					Tables:        []string{},
+				},
+			}
+			assertIdentifyResults(t, query, IdentifyOptions{}, expected, "")
+		})
+
+		t.Run("should count invalid UTF-8 bytes as one byte each", func(t *testing.T) {
+			query := "SELECT '\xff\xfe'; SELECT 1;"
+			expected := []IdentifyResult{
+				{
+					Start:         0,
+					End:           11,
+					StartByte:     0,
+					EndByte:       11,
+					StartLine:     1,
+					StartColumn:   1,
+					EndLine:       1,
+					EndColumn:     12,
+					Text:          "SELECT '\xff\xfe';",
+					Type:          StatementSelect,
+					ExecutionType: ExecutionListing,
+					Parameters:    []string{},
+					Tables:        []string{},
+				},
+				{
+					Start:         13,
+					End:           21,
+					StartByte:     13,
+					EndByte:       21,
+					StartLine:     1,
+					StartColumn:   14,
+					EndLine:       1,
+					EndColumn:     22,
+					Text:          "SELECT 1;",
+					Type:          StatementSelect,
+					ExecutionType: ExecutionListing,
+					Parameters:    []string{},
+					Tables:        []string{},
+				},
+			}
+			assertIdentifyResults(t, query, IdentifyOptions{}, expected, "")
+		})
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		t.Run("should return a *ParseError for an invalid statement", func(t *testing.T) {
+			_, err := Identify("SELECT 1;\nDROP TABLES foo;", IdentifyOptions{})
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError, got %T (%v)", err, err)
+			}
+			if parseErr.StatementIndex != 1 {
+				t.Errorf("Expected statement index 1, got %d", parseErr.StatementIndex)
+			}
+			if parseErr.Token.Value != "TABLES" || parseErr.Line != 2 || parseErr.Column != 6 {
+				t.Errorf("Expected error at TABLES on 2:6, got %q on %d:%d", parseErr.Token.Value, parseErr.Line, parseErr.Column)
+			}
+			if len(parseErr.Expected) == 0 {
+				t.Errorf("Expected the accepted tokens to be reported")
+			}
+		})
+
+		t.Run("should return an error for an invalid custom parameter", func(t *testing.T) {
+			options := IdentifyOptions{ParamTypes: &ParamTypes{Custom: []string{`\{[a-z`}}}
+			assertIdentifyResults(t, "SELECT {a}", options, nil, "Invalid custom parameter")
+		})
+	})
 }
 
 func TestGetExecutionType(t *testing.T) {
@@ -0,0 +1,132 @@
+package sqlqueryidentifier
+
+import "strings"
+
+// words reserved by every dialect, from the core of the SQL standard
+var commonReservedWords = []string{
+	"ALL", "AND", "ANY", "AS", "ASC", "BETWEEN", "BY", "CASE", "CAST", "CHECK", "COLUMN", "CONSTRAINT",
+	"CREATE", "CROSS", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER", "DEFAULT",
+	"DELETE", "DESC", "DISTINCT", "DROP", "ELSE", "END", "EXCEPT", "EXISTS", "FALSE", "FETCH", "FOR",
+	"FOREIGN", "FROM", "FULL", "GRANT", "GROUP", "HAVING", "IN", "INNER", "INSERT", "INTERSECT", "INTO",
+	"IS", "JOIN", "LEFT", "LIKE", "NATURAL", "NOT", "NULL", "ON", "OR", "ORDER", "OUTER", "PRIMARY",
+	"REFERENCES", "RIGHT", "SELECT", "SET", "SOME", "TABLE", "THEN", "TO", "TRUE", "UNION", "UNIQUE",
+	"UPDATE", "USING", "VALUES", "WHEN", "WHERE", "WITH",
+}
+
+// words reserved by each dialect on top of the common ones. Dialects that follow the rules of another
+// dialect share its words
+var dialectReservedWords = map[Dialect][]string{
+	DialectMSSQL: {
+		"ADD", "ALTER", "AUTHORIZATION", "BACKUP", "BEGIN", "BREAK", "BROWSE", "BULK", "CASCADE", "CHECKPOINT",
+		"CLOSE", "CLUSTERED", "COALESCE", "COLLATE", "COMMIT", "COMPUTE", "CONTAINS", "CONTAINSTABLE",
+		"CONTINUE", "CONVERT", "CURRENT", "CURSOR", "DATABASE", "DBCC", "DEALLOCATE", "DECLARE", "DENY",
+		"DISK", "DISTRIBUTED", "DOUBLE", "DUMP", "ERRLVL", "ESCAPE", "EXEC", "EXECUTE", "EXIT", "EXTERNAL",
+		"FILE", "FILLFACTOR", "FREETEXT", "FREETEXTTABLE", "FUNCTION", "GOTO", "HOLDLOCK", "IDENTITY",
+		"IDENTITY_INSERT", "IDENTITYCOL", "IF", "INDEX", "KEY", "KILL", "LINENO", "LOAD", "MERGE", "NATIONAL",
+		"NOCHECK", "NONCLUSTERED", "NULLIF", "OF", "OFF", "OFFSETS", "OPEN", "OPENDATASOURCE", "OPENQUERY",
+		"OPENROWSET", "OPENXML", "OPTION", "OVER", "PERCENT", "PIVOT", "PLAN", "PRECISION", "PRINT", "PROC",
+		"PROCEDURE", "PUBLIC", "RAISERROR", "READ", "READTEXT", "RECONFIGURE", "REPLICATION", "RESTORE",
+		"RESTRICT", "RETURN", "REVERT", "REVOKE", "ROLLBACK", "ROWCOUNT", "ROWGUIDCOL", "RULE", "SAVE",
+		"SCHEMA", "SESSION_USER", "SETUSER", "SHUTDOWN", "STATISTICS", "SYSTEM_USER", "TABLESAMPLE",
+		"TEXTSIZE", "TOP", "TRAN", "TRANSACTION", "TRIGGER", "TRUNCATE", "TRY_CONVERT", "TSEQUAL", "UNPIVOT",
+		"UPDATETEXT", "USE", "USER", "VARYING", "VIEW", "WAITFOR", "WHILE", "WITHIN", "WRITETEXT",
+	},
+	DialectSQLite: {
+		"ABORT", "ACTION", "ADD", "AFTER", "ALTER", "ANALYZE", "ATTACH", "AUTOINCREMENT", "BEFORE", "BEGIN",
+		"CASCADE", "COLLATE", "COMMIT", "CONFLICT", "DATABASE", "DEFERRABLE", "DEFERRED", "DETACH", "EACH",
+		"ESCAPE", "EXCLUSIVE", "EXPLAIN", "FAIL", "GLOB", "IF", "IGNORE", "IMMEDIATE", "INDEX", "INDEXED",
+		"INITIALLY", "INSTEAD", "ISNULL", "KEY", "LIMIT", "MATCH", "NO", "NOTNULL", "OF", "OFFSET", "PLAN",
+		"PRAGMA", "QUERY", "RAISE", "RECURSIVE", "REGEXP", "REINDEX", "RELEASE", "RENAME", "REPLACE",
+		"RESTRICT", "ROLLBACK", "ROW", "SAVEPOINT", "TEMP", "TEMPORARY", "TRANSACTION", "TRIGGER", "VACUUM",
+		"VIEW", "VIRTUAL", "WITHOUT",
+	},
+	DialectMySQL: {
+		"ACCESSIBLE", "ADD", "ALTER", "ANALYZE", "BEFORE", "BIGINT", "BINARY", "BLOB", "BOTH", "CALL",
+		"CASCADE", "CHANGE", "CHAR", "CHARACTER", "COLLATE", "CONDITION", "CONTINUE", "CONVERT", "CURSOR",
+		"DATABASE", "DATABASES", "DAY_HOUR", "DAY_MINUTE", "DAY_SECOND", "DEC", "DECIMAL", "DECLARE",
+		"DELAYED", "DESCRIBE", "DETERMINISTIC", "DISTINCTROW", "DIV", "DOUBLE", "DUAL", "EACH", "ELSEIF",
+		"ENCLOSED", "ESCAPED", "EXIT", "EXPLAIN", "FLOAT", "FORCE", "FULLTEXT", "GENERATED", "HIGH_PRIORITY",
+		"IF", "IGNORE", "INDEX", "INFILE", "INOUT", "INT", "INTEGER", "INTERVAL", "ITERATE", "KEY", "KEYS",
+		"KILL", "LEADING", "LEAVE", "LIMIT", "LINES", "LOAD", "LOCK", "LONG", "LOOP", "LOW_PRIORITY", "MATCH",
+		"MOD", "MODIFIES", "NO_WRITE_TO_BINLOG", "NUMERIC", "OPTIMIZE", "OPTION", "OPTIONALLY", "OUT",
+		"OUTFILE", "OVER", "PARTITION", "PRECISION", "PROCEDURE", "PURGE", "RANGE", "READ", "READS", "REAL",
+		"REGEXP", "RELEASE", "RENAME", "REPEAT", "REPLACE", "REQUIRE", "RESIGNAL", "RESTRICT", "RETURN",
+		"REVOKE", "RLIKE", "SCHEMA", "SCHEMAS", "SENSITIVE", "SEPARATOR", "SHOW", "SIGNAL", "SMALLINT",
+		"SPATIAL", "SPECIFIC", "SQL", "SQLEXCEPTION", "SQLSTATE", "SQLWARNING", "STARTING", "STORED",
+		"STRAIGHT_JOIN", "TERMINATED", "TINYINT", "TRAILING", "TRIGGER", "UNDO", "UNLOCK", "UNSIGNED", "USAGE",
+		"USE", "UTC_DATE", "UTC_TIME", "UTC_TIMESTAMP", "VARBINARY", "VARCHAR", "VARYING", "VIRTUAL", "WHILE",
+		"WINDOW", "WRITE", "XOR", "YEAR_MONTH", "ZEROFILL",
+	},
+	DialectOracle: {
+		"ACCESS", "ADD", "ALTER", "AUDIT", "CLUSTER", "COMMENT", "COMPRESS", "CONNECT", "DATE", "DECIMAL",
+		"EXCLUSIVE", "FILE", "FLOAT", "IDENTIFIED", "IMMEDIATE", "INCREMENT", "INDEX", "INITIAL", "INTEGER",
+		"LEVEL", "LOCK", "LONG", "MAXEXTENTS", "MINUS", "MLSLABEL", "MODE", "MODIFY", "NOAUDIT", "NOCOMPRESS",
+		"NOWAIT", "NUMBER", "OF", "OFFLINE", "ONLINE", "OPTION", "PCTFREE", "PRIOR", "PUBLIC", "RAW",
+		"RENAME", "RESOURCE", "REVOKE", "ROW", "ROWID", "ROWNUM", "ROWS", "SESSION", "SHARE", "SIZE",
+		"SMALLINT", "START", "SUCCESSFUL", "SYNONYM", "SYSDATE", "UID", "USER", "VALIDATE", "VARCHAR",
+		"VARCHAR2", "VIEW", "WHENEVER",
+	},
+	DialectPSQL: {
+		"ANALYSE", "ANALYZE", "ARRAY", "ASYMMETRIC", "AUTHORIZATION", "BINARY", "BOTH", "COLLATE",
+		"COLLATION", "CONCURRENTLY", "CURRENT_CATALOG", "CURRENT_ROLE", "CURRENT_SCHEMA", "DEFERRABLE", "DO",
+		"FREEZE", "ILIKE", "INITIALLY", "ISNULL", "LATERAL", "LEADING", "LIMIT", "LOCALTIME", "LOCALTIMESTAMP",
+		"NOTNULL", "OFFSET", "ONLY", "OVERLAPS", "PLACING", "RETURNING", "SESSION_USER", "SIMILAR", "SYMMETRIC",
+		"TABLESAMPLE", "TRAILING", "USER", "VARIADIC", "VERBOSE", "WINDOW",
+	},
+	DialectBigQuery: {
+		"ARRAY", "ASSERT_ROWS_MODIFIED", "AT", "COLLATE", "CONTAINS", "CUBE", "CURRENT", "DEFINE", "ENUM",
+		"ESCAPE", "EXCLUDE", "EXTRACT", "FOLLOWING", "GROUPING", "GROUPS", "HASH", "IF", "IGNORE", "INTERVAL",
+		"LATERAL", "LIMIT", "LOOKUP", "MERGE", "NEW", "NO", "NULLS", "OF", "OVER", "PARTITION", "PRECEDING",
+		"PROTO", "QUALIFY", "RANGE", "RECURSIVE", "RESPECT", "ROLLUP", "ROWS", "STRUCT", "TABLESAMPLE", "TREAT",
+		"UNBOUNDED", "UNNEST", "WINDOW", "WITHIN",
+	},
+	DialectSnowflake: {
+		"ACCOUNT", "ALTER", "COLUMN", "CONNECT", "CONNECTION", "CURRENT", "DATABASE", "FOLLOWING",
+		"GSCLUSTER", "ILIKE", "INCREMENT", "ISSUE", "LATERAL", "LOCALTIME", "LOCALTIMESTAMP", "MINUS", "OF",
+		"ORGANIZATION", "QUALIFY", "REGEXP", "REVOKE", "RLIKE", "ROW", "ROWS", "SAMPLE", "SCHEMA", "START",
+		"TABLESAMPLE", "TRIGGER", "TRY_CAST", "VIEW", "WHENEVER",
+	},
+	DialectClickHouse: {
+		"ARRAY", "FINAL", "FORMAT", "GLOBAL", "ILIKE", "INTERVAL", "LIMIT", "OFFSET", "PREWHERE", "SAMPLE",
+		"SETTINGS",
+	},
+	DialectDuckDB: {
+		"ANALYSE", "ANALYZE", "ARRAY", "ASYMMETRIC", "BOTH", "COLLATE", "DEFERRABLE", "DO", "ILIKE",
+		"INITIALLY", "LATERAL", "LEADING", "LIMIT", "OFFSET", "ONLY", "PIVOT", "PIVOT_LONGER", "PIVOT_WIDER",
+		"PLACING", "QUALIFY", "RETURNING", "SYMMETRIC", "TRAILING", "UNPIVOT", "VARIADIC", "WINDOW",
+	},
+	DialectSparkSQL: {
+		"AUTHORIZATION", "BOTH", "COLLATE", "FILTER", "LATERAL", "LEADING", "OFFSET", "ONLY", "OVERLAPS",
+		"SESSION_USER", "TIME", "TRAILING", "UNKNOWN", "USER",
+	},
+	DialectTrino: {
+		"ALTER", "CUBE", "CURRENT_CATALOG", "CURRENT_PATH", "CURRENT_ROLE", "CURRENT_SCHEMA", "DEALLOCATE",
+		"DESCRIBE", "ESCAPE", "EXECUTE", "EXTRACT", "GROUPING", "JSON_ARRAY", "JSON_EXISTS", "JSON_OBJECT",
+		"JSON_QUERY", "JSON_TABLE", "JSON_VALUE", "LISTAGG", "LOCALTIME", "LOCALTIMESTAMP", "NORMALIZE",
+		"PREPARE", "RECURSIVE", "ROLLUP", "SKIP", "TRIM", "UESCAPE", "UNNEST",
+	},
+}
+
+var reservedWords = make(map[Dialect]map[string]bool)
+
+func init() {
+	for _, dialect := range DIALECTS {
+		words := make(map[string]bool)
+		for _, word := range commonReservedWords {
+			words[word] = true
+		}
+		for _, word := range dialectReservedWords[baseDialect(dialect)] {
+			words[word] = true
+		}
+		reservedWords[dialect] = words
+	}
+}
+
+// returns whether the word is reserved by the dialect, and so is read as a keyword rather than a name
+func isReservedWord(word string, dialect Dialect) bool {
+	words, ok := reservedWords[dialect]
+	if !ok {
+		words = reservedWords[DialectGeneric]
+	}
+	return words[strings.ToUpper(word)]
+}
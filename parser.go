@@ -1,15 +1,14 @@
 package sqlqueryidentifier
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
-
-	regexp "github.com/wasilibs/go-re2"
 )
 
 type StatementParser interface {
-	AddToken(token Token, nextToken Token)
+	AddToken(token Token, nextToken Token) error
 	GetStatement() *Statement
 }
 
@@ -79,6 +78,96 @@ var ExecutionTypes = map[StatementType]ExecutionType{
 	StatementAlterProcedure:  ExecutionModification,
 	StatementUnknown:         ExecutionUnknown,
 	StatementAnonBlock:       ExecutionAnonBlock,
+
+	StatementBeginTransaction:    ExecutionTransaction,
+	StatementCommit:              ExecutionTransaction,
+	StatementRollback:            ExecutionTransaction,
+	StatementSavepoint:           ExecutionTransaction,
+	StatementReleaseSavepoint:    ExecutionTransaction,
+	StatementRollbackToSavepoint: ExecutionTransaction,
+
+	StatementGrant:       ExecutionPermission,
+	StatementRevoke:      ExecutionPermission,
+	StatementCreateUser:  ExecutionPermission,
+	StatementAlterUser:   ExecutionPermission,
+	StatementDropUser:    ExecutionPermission,
+	StatementCreateRole:  ExecutionPermission,
+	StatementAlterRole:   ExecutionPermission,
+	StatementDropRole:    ExecutionPermission,
+	StatementSetPassword: ExecutionPermission,
+	StatementAlterOwner:  ExecutionPermission,
+
+	StatementAlterSequence: ExecutionModification,
+	StatementAlterType:     ExecutionModification,
+	StatementAlterDomain:   ExecutionModification,
+
+	StatementMerge:   ExecutionModification,
+	StatementReplace: ExecutionModification,
+	StatementCopy:    ExecutionModification,
+
+	StatementCreatePackage:     ExecutionModification,
+	StatementCreatePackageBody: ExecutionModification,
+	StatementCreateType:        ExecutionModification,
+	StatementClientCommand:     ExecutionClientCommand,
+
+	StatementCreateStage:       ExecutionModification,
+	StatementCreatePipe:        ExecutionModification,
+	StatementCreateStream:      ExecutionModification,
+	StatementCreateTask:        ExecutionModification,
+	StatementCopyInto:          ExecutionModification,
+	StatementPut:               ExecutionModification,
+	StatementGet:               ExecutionListing,
+	StatementUseWarehouse:      ExecutionSession,
+	StatementUseDatabase:       ExecutionSession,
+	StatementUseSchema:         ExecutionSession,
+	StatementUseRole:           ExecutionSession,
+	StatementUseSecondaryRoles: ExecutionSession,
+	StatementExecuteImmediate:  ExecutionAnonBlock,
+
+	StatementOptimize:         ExecutionModification,
+	StatementSystem:           ExecutionModification,
+	StatementAttach:           ExecutionModification,
+	StatementDetach:           ExecutionModification,
+	StatementExists:           ExecutionListing,
+	StatementDescribe:         ExecutionListing,
+	StatementShowDictionaries: ExecutionListing,
+	StatementShowClusters:     ExecutionListing,
+	StatementShowCluster:      ExecutionListing,
+	StatementShowSettings:     ExecutionListing,
+	StatementShowUsers:        ExecutionListing,
+	StatementShowRoles:        ExecutionListing,
+	StatementShowFunctions:    ExecutionListing,
+
+	StatementUnload:                 ExecutionModification,
+	StatementVacuum:                 ExecutionModification,
+	StatementCreateExternalSchema:   ExecutionModification,
+	StatementCreateExternalTable:    ExecutionModification,
+	StatementCreateExternalFunction: ExecutionModification,
+	StatementImport:                 ExecutionModification,
+	StatementBackup:                 ExecutionModification,
+	StatementRestore:                ExecutionModification,
+	StatementShowRanges:             ExecutionListing,
+
+	StatementExportDatabase: ExecutionModification,
+	StatementImportDatabase: ExecutionModification,
+	StatementInstall:        ExecutionModification,
+	StatementLoad:           ExecutionSession,
+	StatementCacheTable:     ExecutionModification,
+	StatementMsckRepair:     ExecutionModification,
+
+	StatementCreateSequence: ExecutionModification,
+	StatementDropSequence:   ExecutionModification,
+	StatementShowExplain:    ExecutionListing,
+
+	StatementPrepare:      ExecutionSession,
+	StatementExecute:      ExecutionModification,
+	StatementDeallocate:   ExecutionSession,
+	StatementCall:         ExecutionModification,
+	StatementSetSession:   ExecutionSession,
+	StatementShowCatalogs: ExecutionListing,
+	StatementShowSchemas:  ExecutionListing,
+	StatementShowSession:  ExecutionListing,
+	StatementShowStats:    ExecutionListing,
 }
 
 var statementsWithEnds = []StatementType{
@@ -89,16 +178,36 @@ var statementsWithEnds = []StatementType{
 	StatementUnknown,
 }
 
-var preTableKeywords = regexp.MustCompile(`(?i)^from$|^join$|^into$`)
+// PL/SQL units whose nested blocks end with END; so that only a terminator line ends the statement
+var statementsEndedByTerminator = []StatementType{
+	StatementCreatePackage,
+	StatementCreatePackageBody,
+	StatementCreateType,
+}
+
+// commands that are run by the client when they start a line, rather than being sent to the server
+var clientCommands = map[Dialect][]string{
+	DialectOracle: {
+		"@", "@@", "ACCEPT", "APPEND", "BREAK", "BTITLE", "CLEAR", "COLUMN", "COMPUTE", "CONNECT", "DEFINE",
+		"DESC", "DESCRIBE", "DISCONNECT", "EXIT", "HOST", "PAUSE", "PRINT", "PROMPT", "QUIT",
+		"REM", "REMARK", "RUN", "SET", "SHOW", "SPOOL", "START", "TIMING", "TTITLE", "UNDEFINE", "VAR",
+		"VARIABLE", "WHENEVER",
+	},
+	DialectPSQL: {"\\"},
+}
+
+// SET statements of oracle SQL, as opposed to the SET commands of SQL*Plus
+var oracleSetStatements = []string{"TRANSACTION", "ROLE", "CONSTRAINT", "CONSTRAINTS"}
 
 var blockOpeners = map[Dialect][]string{
-	DialectGeneric:  {"BEGIN", "CASE"},
-	DialectPSQL:     {"BEGIN", "CASE", "LOOP", "IF"},
-	DialectMySQL:    {"BEGIN", "CASE", "LOOP", "IF"},
-	DialectMSSQL:    {"BEGIN", "CASE"},
-	DialectSQLite:   {"BEGIN", "CASE"},
-	DialectOracle:   {"DECLARE", "BEGIN", "CASE"},
-	DialectBigQuery: {"BEGIN", "CASE", "IF", "LOOP", "REPEAT", "WHILE", "FOR"},
+	DialectGeneric:   {"BEGIN", "CASE"},
+	DialectPSQL:      {"BEGIN", "CASE", "LOOP", "IF"},
+	DialectMySQL:     {"BEGIN", "CASE", "LOOP", "IF"},
+	DialectMSSQL:     {"BEGIN", "CASE"},
+	DialectSQLite:    {"BEGIN", "CASE"},
+	DialectOracle:    {"DECLARE", "BEGIN", "CASE"},
+	DialectBigQuery:  {"BEGIN", "CASE", "IF", "LOOP", "REPEAT", "WHILE", "FOR"},
+	DialectSnowflake: {"DECLARE", "BEGIN", "CASE", "IF", "LOOP", "REPEAT", "WHILE", "FOR"},
 }
 
 type ParseOptions struct {
@@ -115,6 +224,12 @@ type cteState struct {
 	parens       int
 	state        *State
 	params       []string
+
+	// used to identify the names of the CTEs, the type of their bodies and the tables they use
+	expectName bool
+	bodyType   *StatementType
+	statement  *Statement
+	tables     *tableReader
 }
 
 func createInitialStatement() *Statement {
@@ -129,10 +244,14 @@ func createInitialStatement() *Statement {
 func initState(input []rune, prevState *State) *State {
 	if prevState != nil {
 		return &State{
-			Input:    prevState.Input,
-			Position: prevState.Position,
-			Start:    prevState.Position + 1,
-			End:      len(prevState.Input) - 1,
+			Input:     prevState.Input,
+			Position:  prevState.Position,
+			Start:     prevState.Position + 1,
+			End:       len(prevState.Input) - 1,
+			source:    prevState.source,
+			delimiter: prevState.delimiter,
+
+			executableComment: prevState.executableComment,
 		}
 	}
 	return &State{
@@ -156,9 +275,16 @@ func nextNonWhitespaceToken(state *State, dialect Dialect, paramTypes *ParamType
 	return token
 }
 
-func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, paramTypes *ParamTypes) *ParseResult {
+func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, paramTypes *ParamTypes) (*ParseResult, error) {
+	if paramTypes != nil {
+		if err := validateCustomParams(paramTypes); err != nil {
+			return nil, err
+		}
+	}
+
 	inputRunes := []rune(input)
 	topLevelState := initState(inputRunes, nil)
+	topLevelState.source = newSourceIndex(input)
 	topLevelResult := &ParseResult{
 		Type:   "QUERY",
 		Start:  0,
@@ -181,18 +307,81 @@ func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, pa
 		TokenSemicolon,
 	}
 
+	// the index of the current MSSQL batch, and the end of the last token added to the current statement
+	batch := 0
+	lastTokenEnd := 0
+
+	// the start of the mysql executable comment the next statement is written in, if any
+	executableCommentStart := -1
+
 	for prevState.Position < topLevelState.End {
 		tokenState := initState(nil, prevState)
 		token := ScanToken(tokenState, dialect, paramTypes)
 		nextToken := nextNonWhitespaceToken(tokenState, dialect, paramTypes)
 
+		// client commands take the rest of their line and are reported as statements of their own
+		if statementParser == nil && !cte.isCte && isClientCommand(token, nextToken, dialect) && isLineStart(tokenState) {
+			token = scanClientCommand(tokenState)
+			topLevelResult.Tokens = append(topLevelResult.Tokens, token)
+			prevState = tokenState
+			topLevelResult.Body = append(topLevelResult.Body, ConcreteStatement{
+				Start:         token.Start,
+				End:           token.End,
+				Type:          StatementClientCommand,
+				ExecutionType: ExecutionClientCommand,
+				Parameters:    []string{},
+				Tables:        []string{},
+				Batch:         batch,
+			})
+			continue
+		}
+
+		// a GO line, a DELIMITER line or a custom delimiter ends the statement being parsed, whether or not
+		// it has reached its end, and is left out of the statement
+		if token.Type == TokenBatchSeparator || token.Type == TokenDelimiterDirective || token.Type == TokenDelimiter {
+			if statementParser != nil {
+				statement := statementParser.GetStatement()
+				statement.End = lastTokenEnd
+				if token.Type == TokenDelimiter {
+					statement.EndStatement = &token.Value
+				}
+				topLevelResult.Body = append(topLevelResult.Body, statement.ToConcrete())
+				statementParser = nil
+			}
+			// a CTE that has not reached its statement ends here too, rather than carrying on past the line
+			if cte.isCte {
+				topLevelResult.Body = append(topLevelResult.Body, cte.unknownStatement(lastNonBlankTokenEnd(topLevelResult.Tokens), batch))
+				cte.reset()
+			}
+			if token.Type == TokenBatchSeparator {
+				batch++
+			}
+			topLevelResult.Tokens = append(topLevelResult.Tokens, token)
+			prevState = tokenState
+			continue
+		}
+
 		if statementParser == nil {
+			// a statement written in an executable comment starts with the comment
+			if !cte.isCte && token.Type == TokenExecutableComment {
+				if token.Value == "*/" {
+					executableCommentStart = -1
+				} else {
+					executableCommentStart = token.Start
+				}
+				topLevelResult.Tokens = append(topLevelResult.Tokens, token)
+				prevState = tokenState
+				continue
+			}
+
 			// ignore blank tokens before the start of a CTE / not part of a statement
 			if !cte.isCte && slices.Contains(ignoreOutsideBlankTokens, token.Type) {
 				topLevelResult.Tokens = append(topLevelResult.Tokens, token)
 				prevState = tokenState
 			} else if !cte.isCte && token.Type == TokenKeyword && strings.ToUpper(token.Value) == "WITH" {
 				cte.isCte = true
+				cte.expectName = true
+				cte.statement = createInitialStatement()
 				topLevelResult.Tokens = append(topLevelResult.Tokens, token)
 				cte.state = tokenState
 				prevState = tokenState
@@ -202,19 +391,10 @@ func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, pa
 			} else if cte.isCte && token.Type == TokenSemicolon {
 				topLevelResult.Tokens = append(topLevelResult.Tokens, token)
 				prevState = tokenState
-				topLevelResult.Body = append(topLevelResult.Body, ConcreteStatement{
-					Start:         cte.state.Start,
-					End:           token.End,
-					Type:          StatementUnknown,
-					ExecutionType: ExecutionUnknown,
-					Parameters:    []string{},
-					Tables:        []string{},
-				})
-				cte.isCte = false
-				cte.asSeen = false
-				cte.statementEnd = false
-				cte.parens = 0
+				topLevelResult.Body = append(topLevelResult.Body, cte.unknownStatement(token.End, batch))
+				cte.reset()
 			} else if cte.isCte && !cte.statementEnd {
+				cte.readBody(token, nextToken, dialect, identifyTables)
 				if cte.asSeen {
 					switch token.Value {
 					case "(":
@@ -233,28 +413,46 @@ func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, pa
 			} else if cte.isCte && cte.statementEnd && token.Value == "," {
 				cte.asSeen = false
 				cte.statementEnd = false
+				cte.expectName = true
+				cte.bodyType = nil
 				topLevelResult.Tokens = append(topLevelResult.Tokens, token)
 				prevState = tokenState
 			} else if cte.isCte && cte.statementEnd && slices.Contains(ignoreOutsideBlankTokens, token.Type) {
 				topLevelResult.Tokens = append(topLevelResult.Tokens, token)
 				prevState = tokenState
 			} else {
-				statementParser = createStatementParserByToken(token, nextToken, ParseOptions{
+				var err error
+				statementParser, err = createStatementParserByToken(token, nextToken, ParseOptions{
 					IsStrict:       isStrict,
 					Dialect:        dialect,
 					IdentifyTables: identifyTables,
 					ParamTypes:     paramTypes,
 				})
+				if err != nil {
+					return nil, locateParseError(err, len(topLevelResult.Body))
+				}
+				statementParser.GetStatement().Batch = batch
+				if executableCommentStart >= 0 {
+					statementParser.GetStatement().Start = executableCommentStart
+					executableCommentStart = -1
+				}
 				if cte.isCte {
 					stmt := statementParser.GetStatement()
 					stmt.Start = cte.state.Start
 					isCte := true
 					stmt.IsCte = &isCte
 					stmt.Parameters = append(stmt.Parameters, cte.params...)
+					stmt.ModifyingCTEs = cte.statement.ModifyingCTEs
+					if identifyTables {
+						stmt.Tables = cte.statement.Tables
+						stmt.TableRefs = cte.statement.TableRefs
+						stmt.CTEs = cte.statement.CTEs
+					}
 					cte.params = []string{}
 					cte.isCte = false
 					cte.asSeen = false
 					cte.statementEnd = false
+					cte.bodyType = nil
 				}
 			}
 
@@ -262,15 +460,33 @@ func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, pa
 				cte.params = append(cte.params, token.Value)
 			}
 		} else {
-			statementParser.AddToken(token, nextToken)
+			if err := statementParser.AddToken(token, nextToken); err != nil {
+				return nil, locateParseError(err, len(topLevelResult.Body))
+			}
 			topLevelResult.Tokens = append(topLevelResult.Tokens, token)
 			prevState = tokenState
+			if token.Type != TokenWhitespace {
+				lastTokenEnd = token.End
+			}
 
 			statement := statementParser.GetStatement()
-			if statement.EndStatement != nil {
+			if statement.EndStatement == nil && statement.endsWithLine && isCommandLineEnd(token, topLevelResult.Tokens) {
+				statement.End = lastTokenEnd
+				topLevelResult.Body = append(topLevelResult.Body, statement.ToConcrete())
+				statementParser = nil
+			} else if statement.EndStatement != nil {
 				statement.End = token.End
 				topLevelResult.Body = append(topLevelResult.Body, statement.ToConcrete())
 				statementParser = nil
+
+				// the rows loaded by COPY ... FROM stdin are data rather than SQL, and are kept with the statement
+				if statement.copyFromStdin && tokenState.Position < topLevelState.End {
+					dataState := initState(nil, tokenState)
+					dataToken := scanCopyData(dataState)
+					topLevelResult.Tokens = append(topLevelResult.Tokens, dataToken)
+					topLevelResult.Body[len(topLevelResult.Body)-1].CopyData = copyDataRows(dataToken.Value)
+					prevState = dataState
+				}
 			}
 		}
 	}
@@ -284,57 +500,320 @@ func Parse(input string, isStrict bool, dialect Dialect, identifyTables bool, pa
 		}
 	}
 
-	return topLevelResult
+	return topLevelResult, nil
+}
+
+// reports a CTE that ended before its statement started as an unknown statement
+func (c *cteState) unknownStatement(end int, batch int) ConcreteStatement {
+	return ConcreteStatement{
+		Start:         c.state.Start,
+		End:           end,
+		Type:          StatementUnknown,
+		ExecutionType: ExecutionUnknown,
+		Parameters:    []string{},
+		Tables:        []string{},
+		Batch:         batch,
+	}
+}
+
+// forgets a CTE that ended before its statement started
+func (c *cteState) reset() {
+	c.isCte = false
+	c.asSeen = false
+	c.statementEnd = false
+	c.parens = 0
+	c.bodyType = nil
+	c.params = []string{}
+}
+
+// returns the end of the last token that is not whitespace or a comment
+func lastNonBlankTokenEnd(tokens []Token) int {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].Type != TokenWhitespace && tokens[i].Type != TokenCommentInline && tokens[i].Type != TokenCommentBlock {
+			return tokens[i].End
+		}
+	}
+	return 0
+}
+
+// records the name of each CTE and the type of its body, which is read as a statement of its own so
+// that data-modifying CTEs and the tables used by the body can be identified
+func (c *cteState) readBody(token Token, nextToken Token, dialect Dialect, identifyTables bool) {
+	if !c.asSeen {
+		if c.expectName && isIdentifierToken(token) && strings.ToUpper(token.Value) != "RECURSIVE" {
+			name, _ := unquoteIdentifier(token.Value)
+			c.statement.CTEs = append(c.statement.CTEs, name)
+			c.expectName = false
+		}
+		return
+	}
+
+	if c.bodyType == nil {
+		var bodyType StatementType
+		switch strings.ToUpper(token.Value) {
+		case "SELECT":
+			bodyType = StatementSelect
+		case "INSERT":
+			bodyType = StatementInsert
+		case "UPDATE":
+			bodyType = StatementUpdate
+		case "DELETE":
+			bodyType = StatementDelete
+		case "MERGE":
+			bodyType = StatementMerge
+		default:
+			return
+		}
+		c.bodyType = &bodyType
+		c.tables = newTableReader(c.statement, dialect)
+
+		if ExecutionTypes[bodyType] == ExecutionModification && len(c.statement.CTEs) > 0 {
+			c.statement.ModifyingCTEs = append(c.statement.ModifyingCTEs, c.statement.CTEs[len(c.statement.CTEs)-1])
+		}
+	}
+
+	if identifyTables && !c.tables.addToken(token, nextToken) {
+		if keyword, ok := findTableKeyword(*c.bodyType, token, nextToken, c.tables.found, c.tables.setSeen); ok {
+			c.tables.expect(keyword)
+		}
+	}
+}
+
+// the rows of a COPY data section, without the rest of the statement's line before them and the \. line after them
+func copyDataRows(value string) string {
+	_, rows, _ := strings.Cut(value, "\n")
+	return strings.TrimSuffix(rows, `\.`)
+}
+
+func isClientCommand(token Token, nextToken Token, dialect Dialect) bool {
+	upperValue := strings.ToUpper(token.Value)
+	if dialect == DialectOracle && upperValue == "SET" {
+		return !slices.Contains(oracleSetStatements, strings.ToUpper(nextToken.Value))
+	}
+	return slices.Contains(clientCommands[baseDialect(dialect)], upperValue)
+}
+
+// returns whether the whitespace token ends the line of a SQL*Plus command, which a trailing - continues
+func isCommandLineEnd(token Token, tokens []Token) bool {
+	if token.Type != TokenWhitespace || !strings.Contains(token.Value, "\n") {
+		return false
+	}
+	return len(tokens) < 2 || tokens[len(tokens)-2].Value != "-"
+}
+
+// fills in the position of the offending token and the index of the statement being parsed
+func locateParseError(err error, statementIndex int) error {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+
+	parseErr.StatementIndex = statementIndex
+	parseErr.Offset = parseErr.Token.Start
+	parseErr.ByteOffset = parseErr.Token.StartByte
+	parseErr.Line = parseErr.Token.StartLine
+	parseErr.Column = parseErr.Token.StartColumn
+	return parseErr
 }
 
-func createStatementParserByToken(token Token, nextToken Token, options ParseOptions) StatementParser {
+func createStatementParserByToken(token Token, nextToken Token, options ParseOptions) (StatementParser, error) {
+	base := baseDialect(options.Dialect)
+
+	// duckdb queries can start with their FROM clause
+	if options.Dialect == DialectDuckDB && token.Type == TokenKeyword && strings.ToUpper(token.Value) == "FROM" {
+		return createSelectStatementParser(options), nil
+	}
+
 	if token.Type == TokenKeyword {
 		switch strings.ToUpper(token.Value) {
 		case "SELECT":
-			return createSelectStatementParser(options)
+			return createSelectStatementParser(options), nil
 		case "CREATE":
-			return createCreateStatementParser(options)
+			if options.Dialect == DialectRedshift && strings.ToUpper(nextToken.Value) == "EXTERNAL" {
+				return createCreateExternalStatementParser(options), nil
+			}
+			return createCreateStatementParser(options), nil
 		case "SHOW":
-			if options.Dialect == DialectMySQL || options.Dialect == DialectGeneric {
-				return createShowStatementParser(options)
+			if base == DialectMySQL || options.Dialect == DialectGeneric || options.Dialect == DialectClickHouse ||
+				options.Dialect == DialectCockroachDB || options.Dialect == DialectTrino {
+				return createShowStatementParser(options), nil
 			}
 		case "DROP":
-			return createDropStatementParser(options)
+			return createDropStatementParser(options), nil
 		case "ALTER":
-			return createAlterStatementParser(options)
+			return createAlterStatementParser(options), nil
 		case "INSERT":
-			return createInsertStatementParser(options)
+			return createInsertStatementParser(options), nil
 		case "UPDATE":
-			return createUpdateStatementParser(options)
+			return createUpdateStatementParser(options), nil
 		case "DELETE":
-			return createDeleteStatementParser(options)
+			return createDeleteStatementParser(options), nil
 		case "TRUNCATE":
-			return createTruncateStatementParser(options)
+			return createTruncateStatementParser(options), nil
+		case "COPY":
+			if base == DialectPSQL || options.Dialect == DialectDuckDB {
+				return createCopyStatementParser(options), nil
+			}
+			if options.Dialect == DialectSnowflake && strings.ToUpper(nextToken.Value) == "INTO" {
+				return createCopyIntoStatementParser(options), nil
+			}
+		case "MERGE":
+			if base != DialectMySQL && options.Dialect != DialectSQLite {
+				return createMergeStatementParser(options), nil
+			}
+		case "REPLACE":
+			if base == DialectMySQL || options.Dialect == DialectSQLite || options.Dialect == DialectGeneric {
+				return createReplaceStatementParser(options), nil
+			}
 		case "BEGIN":
-			if (options.Dialect == DialectBigQuery || options.Dialect == DialectOracle) && strings.ToUpper(nextToken.Value) != "TRANSACTION" {
-				return createBlockStatementParser(options)
+			if isTransactionBegin(nextToken, options.Dialect) {
+				return createBeginTransactionStatementParser(options), nil
+			}
+			if options.Dialect == DialectBigQuery || options.Dialect == DialectOracle || options.Dialect == DialectSnowflake {
+				return createBlockStatementParser(options), nil
+			}
+			if options.Dialect == DialectMariaDB && strings.ToUpper(nextToken.Value) == "NOT" {
+				// BEGIN NOT ATOMIC ... END
+				return createBlockStatementParser(options), nil
+			}
+		case "START":
+			if strings.ToUpper(nextToken.Value) == "TRANSACTION" {
+				return createStartTransactionStatementParser(options), nil
+			}
+		case "COMMIT":
+			return createCommitStatementParser(options), nil
+		case "END":
+			if base == DialectPSQL || options.Dialect == DialectSQLite {
+				return createCommitStatementParser(options), nil
+			}
+		case "ROLLBACK":
+			return createRollbackStatementParser(options), nil
+		case "ABORT":
+			if base == DialectPSQL {
+				return createRollbackStatementParser(options), nil
+			}
+		case "SAVEPOINT":
+			return createSavepointStatementParser(options), nil
+		case "SAVE":
+			if options.Dialect == DialectMSSQL {
+				return createSavepointStatementParser(options), nil
+			}
+		case "RELEASE":
+			return createReleaseSavepointStatementParser(options), nil
+		case "GRANT":
+			return createGrantStatementParser(options), nil
+		case "REVOKE":
+			return createRevokeStatementParser(options), nil
+		case "SET":
+			if base == DialectMySQL && strings.ToUpper(nextToken.Value) == "PASSWORD" {
+				return createSetPasswordStatementParser(options), nil
+			}
+			if options.Dialect == DialectTrino && strings.ToUpper(nextToken.Value) == "SESSION" {
+				return createSetSessionStatementParser(options), nil
+			}
+			if base == DialectMySQL {
+				return createSetVariableStatementParser(options), nil
 			}
 		case "DECLARE":
+			if options.Dialect == DialectOracle || options.Dialect == DialectSnowflake {
+				return createBlockStatementParser(options), nil
+			}
+		case "OPTIMIZE", "SYSTEM", "ATTACH", "DETACH", "EXISTS", "DESCRIBE", "DESC":
+			if options.Dialect == DialectClickHouse {
+				return createKeywordStatementParser(options), nil
+			}
+			if options.Dialect == DialectDuckDB && (strings.ToUpper(token.Value) == "ATTACH" || strings.ToUpper(token.Value) == "DETACH") {
+				return createKeywordStatementParser(options), nil
+			}
+			if options.Dialect == DialectSparkSQL && strings.ToUpper(token.Value) == "OPTIMIZE" {
+				return createKeywordStatementParser(options), nil
+			}
+		case "PIVOT", "UNPIVOT":
+			if options.Dialect == DialectDuckDB {
+				return createSelectStatementParser(options), nil
+			}
+		case "EXPORT":
+			if options.Dialect == DialectDuckDB {
+				return createDatabaseTransferStatementParser(options), nil
+			}
+		case "INSTALL", "LOAD":
+			if options.Dialect == DialectDuckDB {
+				return createKeywordStatementParser(options), nil
+			}
+		case "CACHE", "MSCK":
+			if options.Dialect == DialectSparkSQL {
+				return createKeywordStatementParser(options), nil
+			}
+		case "VACUUM":
+			if base == DialectPSQL || options.Dialect == DialectSparkSQL {
+				return createKeywordStatementParser(options), nil
+			}
+		case "UNLOAD":
+			if options.Dialect == DialectRedshift {
+				return createKeywordStatementParser(options), nil
+			}
+		case "IMPORT", "BACKUP", "RESTORE":
+			if options.Dialect == DialectDuckDB && strings.ToUpper(token.Value) == "IMPORT" {
+				return createDatabaseTransferStatementParser(options), nil
+			}
+			if options.Dialect == DialectCockroachDB {
+				return createKeywordStatementParser(options), nil
+			}
+		case "PUT", "GET":
+			if options.Dialect == DialectSnowflake {
+				return createFileTransferStatementParser(options), nil
+			}
+		case "USE":
+			if _, ok := useStatementTypes[strings.ToUpper(nextToken.Value)]; ok && options.Dialect == DialectSnowflake {
+				return createUseStatementParser(options), nil
+			}
+		case "EXEC":
+			if options.Dialect == DialectOracle {
+				return createOracleExecStatementParser(options), nil
+			}
+		case "EXECUTE":
 			if options.Dialect == DialectOracle {
-				return createBlockStatementParser(options)
+				return createOracleExecStatementParser(options), nil
+			}
+			if options.Dialect == DialectSnowflake && strings.ToUpper(nextToken.Value) == "IMMEDIATE" {
+				return createExecuteImmediateStatementParser(options), nil
+			}
+			if options.Dialect == DialectTrino {
+				return createKeywordStatementParser(options), nil
+			}
+		case "PREPARE", "DEALLOCATE", "CALL":
+			if options.Dialect == DialectTrino {
+				return createKeywordStatementParser(options), nil
 			}
 		}
 	}
 
 	if !options.IsStrict {
-		return createUnknownStatementParser(options)
+		return createUnknownStatementParser(options), nil
 	}
 
-	panic(fmt.Sprintf("Invalid statement parser \"%s\"", token.Value))
+	return nil, &ParseError{
+		Message: fmt.Sprintf("Invalid statement parser \"%s\"", token.Value),
+		Token:   token,
+	}
 }
 
 func createSelectStatementParser(options ParseOptions) StatementParser {
 	statement := createInitialStatement()
+	acceptTokens := []AcceptToken{{Type: "keyword", Value: "SELECT"}}
+	if options.Dialect == DialectDuckDB {
+		acceptTokens = append(acceptTokens,
+			AcceptToken{Type: "keyword", Value: "FROM"},
+			AcceptToken{Type: "keyword", Value: "PIVOT"},
+			AcceptToken{Type: "keyword", Value: "UNPIVOT"},
+		)
+	}
+
 	steps := []Step{
 		{
 			PreCanGoToNext: func(token *Token) bool { return false },
 			Validation: &StepValidation{
-				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SELECT"}},
+				AcceptTokens: acceptTokens,
 			},
 			Add: func(token Token) {
 				statementType := StatementSelect
@@ -355,7 +834,7 @@ func createBlockStatementParser(options ParseOptions) StatementParser {
 	statement.Type = &statementType
 
 	acceptTokens := []AcceptToken{}
-	if options.Dialect == DialectOracle {
+	if options.Dialect == DialectOracle || options.Dialect == DialectSnowflake {
 		acceptTokens = append(acceptTokens, AcceptToken{Type: "keyword", Value: "DECLARE"})
 	}
 	acceptTokens = append(acceptTokens, AcceptToken{Type: "keyword", Value: "BEGIN"})
@@ -395,6 +874,61 @@ func createInsertStatementParser(options ParseOptions) StatementParser {
 			PostCanGoToNext: func(token *Token) bool { return true },
 		},
 	}
+	if options.Dialect == DialectSQLite {
+		steps = append(steps, Step{
+			// INSERT OR REPLACE INTO
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Add: func(token Token) {
+				if strings.ToUpper(token.Value) == "REPLACE" {
+					statementType := StatementReplace
+					statement.Type = &statementType
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return strings.ToUpper(token.Value) != "OR" },
+		})
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createReplaceStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "REPLACE"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementReplace
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createMergeStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "MERGE"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementMerge
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
 	return stateMachineStatementParser(statement, steps, options)
 }
 
@@ -449,6 +983,12 @@ func createCreateStatementParser(options ParseOptions) StatementParser {
 			AcceptToken{Type: "keyword", Value: "SCHEMA"},
 			AcceptToken{Type: "keyword", Value: "PROCEDURE"},
 		)
+		if options.Dialect != DialectBigQuery {
+			acceptTokens = append(acceptTokens,
+				AcceptToken{Type: "keyword", Value: "USER"},
+				AcceptToken{Type: "keyword", Value: "ROLE"},
+			)
+		}
 	}
 	acceptTokens = append(acceptTokens,
 		AcceptToken{Type: "keyword", Value: "TABLE"},
@@ -457,6 +997,23 @@ func createCreateStatementParser(options ParseOptions) StatementParser {
 		AcceptToken{Type: "keyword", Value: "FUNCTION"},
 		AcceptToken{Type: "keyword", Value: "INDEX"},
 	)
+	if options.Dialect == DialectOracle {
+		acceptTokens = append(acceptTokens,
+			AcceptToken{Type: "keyword", Value: "PACKAGE"},
+			AcceptToken{Type: "keyword", Value: "TYPE"},
+		)
+	}
+	if options.Dialect == DialectMariaDB {
+		acceptTokens = append(acceptTokens, AcceptToken{Type: "keyword", Value: "SEQUENCE"})
+	}
+	if options.Dialect == DialectSnowflake {
+		acceptTokens = append(acceptTokens,
+			AcceptToken{Type: "keyword", Value: "STAGE"},
+			AcceptToken{Type: "keyword", Value: "PIPE"},
+			AcceptToken{Type: "keyword", Value: "STREAM"},
+			AcceptToken{Type: "keyword", Value: "TASK"},
+		)
+	}
 
 	steps := []Step{
 		{
@@ -484,6 +1041,74 @@ func createCreateStatementParser(options ParseOptions) StatementParser {
 			PostCanGoToNext: func(token *Token) bool { return true },
 		},
 	}
+	if options.Dialect == DialectOracle {
+		steps = append(steps, Step{
+			// CREATE PACKAGE BODY and CREATE TYPE BODY
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Add: func(token Token) {
+				if *statement.Type == StatementCreatePackage && strings.ToUpper(token.Value) == "BODY" {
+					statementType := StatementCreatePackageBody
+					statement.Type = &statementType
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		})
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// the objects redshift creates over data stored outside of the cluster
+var externalStatementTypes = map[string]StatementType{
+	"SCHEMA":   StatementCreateExternalSchema,
+	"TABLE":    StatementCreateExternalTable,
+	"FUNCTION": StatementCreateExternalFunction,
+}
+
+func createCreateExternalStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "CREATE"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens:  []AcceptToken{{Type: "keyword", Value: "EXTERNAL"}},
+			},
+			Add:             func(token Token) {},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens: []AcceptToken{
+					{Type: "keyword", Value: "SCHEMA"},
+					{Type: "keyword", Value: "TABLE"},
+					{Type: "keyword", Value: "FUNCTION"},
+				},
+			},
+			Add: func(token Token) {
+				// strict mode rejects any other object; without it the statement is unknown
+				statementType, ok := externalStatementTypes[strings.ToUpper(token.Value)]
+				if !ok {
+					statementType = StatementUnknown
+				}
+				statement.Type = &statementType
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
 	return stateMachineStatementParser(statement, steps, options)
 }
 
@@ -496,6 +1121,12 @@ func createDropStatementParser(options ParseOptions) StatementParser {
 			AcceptToken{Type: "keyword", Value: "SCHEMA"},
 			AcceptToken{Type: "keyword", Value: "PROCEDURE"},
 		)
+		if options.Dialect != DialectBigQuery {
+			acceptTokens = append(acceptTokens,
+				AcceptToken{Type: "keyword", Value: "USER"},
+				AcceptToken{Type: "keyword", Value: "ROLE"},
+			)
+		}
 	}
 	acceptTokens = append(acceptTokens,
 		AcceptToken{Type: "keyword", Value: "TABLE"},
@@ -504,6 +1135,9 @@ func createDropStatementParser(options ParseOptions) StatementParser {
 		AcceptToken{Type: "keyword", Value: "FUNCTION"},
 		AcceptToken{Type: "keyword", Value: "INDEX"},
 	)
+	if options.Dialect == DialectMariaDB {
+		acceptTokens = append(acceptTokens, AcceptToken{Type: "keyword", Value: "SEQUENCE"})
+	}
 
 	steps := []Step{
 		{
@@ -546,13 +1180,25 @@ func createAlterStatementParser(options ParseOptions) StatementParser {
 			AcceptToken{Type: "keyword", Value: "INDEX"},
 		)
 		if options.Dialect != DialectBigQuery {
-			acceptTokens = append(acceptTokens, AcceptToken{Type: "keyword", Value: "PROCEDURE"})
+			acceptTokens = append(acceptTokens,
+				AcceptToken{Type: "keyword", Value: "PROCEDURE"},
+				AcceptToken{Type: "keyword", Value: "USER"},
+				AcceptToken{Type: "keyword", Value: "ROLE"},
+			)
 		}
 	}
 	acceptTokens = append(acceptTokens,
 		AcceptToken{Type: "keyword", Value: "TABLE"},
 		AcceptToken{Type: "keyword", Value: "VIEW"},
 	)
+	// other objects psql can change the owner of with ALTER ... OWNER TO
+	if baseDialect(options.Dialect) == DialectPSQL {
+		acceptTokens = append(acceptTokens,
+			AcceptToken{Type: "keyword", Value: "SEQUENCE"},
+			AcceptToken{Type: "keyword", Value: "TYPE"},
+			AcceptToken{Type: "keyword", Value: "DOMAIN"},
+		)
+	}
 
 	steps := []Step{
 		{
@@ -604,40 +1250,401 @@ func createTruncateStatementParser(options ParseOptions) StatementParser {
 	return stateMachineStatementParser(statement, steps, options)
 }
 
-func createShowStatementParser(options ParseOptions) StatementParser {
+func createCopyStatementParser(options ParseOptions) StatementParser {
 	statement := createInitialStatement()
 	steps := []Step{
 		{
 			PreCanGoToNext: func(token *Token) bool { return false },
 			Validation: &StepValidation{
-				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SHOW"}},
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "COPY"}},
 			},
 			Add: func(token Token) {
+				statementType := StatementCopy
+				statement.Type = &statementType
 				if statement.Start < 0 {
 					statement.Start = token.Start
 				}
 			},
 			PostCanGoToNext: func(token *Token) bool { return true },
 		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createCopyIntoStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
 		{
 			PreCanGoToNext: func(token *Token) bool { return false },
 			Validation: &StepValidation{
-				RequireBefore: []string{string(TokenWhitespace)},
-				AcceptTokens: []AcceptToken{
-					{Type: "keyword", Value: "DATABASES"}, {Type: "keyword", Value: "DATABASE"},
-					{Type: "keyword", Value: "KEYS"}, {Type: "keyword", Value: "INDEX"},
-					{Type: "keyword", Value: "COLUMNS"}, {Type: "keyword", Value: "TABLES"},
-					{Type: "keyword", Value: "TABLE"}, {Type: "keyword", Value: "BINARY"},
-					{Type: "keyword", Value: "BINLOG"}, {Type: "keyword", Value: "CHARACTER"},
-					{Type: "keyword", Value: "COLLATION"}, {Type: "keyword", Value: "CREATE"},
-					{Type: "keyword", Value: "ENGINE"}, {Type: "keyword", Value: "ENGINES"},
-					{Type: "keyword", Value: "ERRORS"}, {Type: "keyword", Value: "EVENTS"},
-					{Type: "keyword", Value: "FUNCTION"}, {Type: "keyword", Value: "GRANTS"},
-					{Type: "keyword", Value: "MASTER"}, {Type: "keyword", Value: "OPEN"},
-					{Type: "keyword", Value: "PLUGINS"}, {Type: "keyword", Value: "PRIVILEGES"},
-					{Type: "keyword", Value: "PROCEDURE"}, {Type: "keyword", Value: "PROCESSLIST"},
-					{Type: "keyword", Value: "PROFILE"}, {Type: "keyword", Value: "PROFILES"},
-					{Type: "keyword", Value: "RELAYLOG"}, {Type: "keyword", Value: "REPLICAS"},
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "COPY"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementCopyInto
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// the statement types of the keywords parsed by createKeywordStatementParser that are not named after the keyword
+var keywordStatementTypes = map[string]StatementType{
+	"DESC":  StatementDescribe,
+	"CACHE": StatementCacheTable,
+	"MSCK":  StatementMsckRepair,
+}
+
+// duckdb EXPORT DATABASE and IMPORT DATABASE
+func createDatabaseTransferStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{
+					{Type: "keyword", Value: "EXPORT"},
+					{Type: "keyword", Value: "IMPORT"},
+				},
+			},
+			Add: func(token Token) {
+				statementType := StatementType(strings.ToUpper(token.Value) + "_DATABASE")
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens:  []AcceptToken{{Type: "keyword", Value: "DATABASE"}},
+			},
+			Add:             func(token Token) {},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// statements named by their first keyword alone, such as OPTIMIZE TABLE or SYSTEM RELOAD DICTIONARIES
+func createKeywordStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Add: func(token Token) {
+				statementType, ok := keywordStatementTypes[strings.ToUpper(token.Value)]
+				if !ok {
+					statementType = StatementType(strings.ToUpper(token.Value))
+				}
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// the SQL*Plus EXEC command wraps a PL/SQL call in BEGIN ... END; and sends it to the server, so it runs an
+// anonymous block. It ends with its line, whether or not it has a semicolon
+func createOracleExecStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	statement.endsWithLine = true
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{
+					{Type: "keyword", Value: "EXEC"},
+					{Type: "keyword", Value: "EXECUTE"},
+				},
+			},
+			Add: func(token Token) {
+				statementType := StatementAnonBlock
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// PUT uploads files to a stage and GET downloads them
+func createFileTransferStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{
+					{Type: "keyword", Value: "PUT"},
+					{Type: "keyword", Value: "GET"},
+				},
+			},
+			Add: func(token Token) {
+				statementType := StatementType(strings.ToUpper(token.Value))
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// the objects a snowflake USE statement switches the session to
+var useStatementTypes = map[string]StatementType{
+	"WAREHOUSE": StatementUseWarehouse,
+	"DATABASE":  StatementUseDatabase,
+	"SCHEMA":    StatementUseSchema,
+	"ROLE":      StatementUseRole,
+	"SECONDARY": StatementUseSecondaryRoles,
+}
+
+func createUseStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "USE"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens: []AcceptToken{
+					{Type: "keyword", Value: "WAREHOUSE"},
+					{Type: "keyword", Value: "DATABASE"},
+					{Type: "keyword", Value: "SCHEMA"},
+					{Type: "keyword", Value: "ROLE"},
+					{Type: "keyword", Value: "SECONDARY"},
+				},
+			},
+			Add: func(token Token) {
+				statementType := useStatementTypes[strings.ToUpper(token.Value)]
+				statement.Type = &statementType
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createSetSessionStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SET"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens:  []AcceptToken{{Type: "keyword", Value: "SESSION"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementSetSession
+				statement.Type = &statementType
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// a mysql SET of system or user variables, such as the SET NAMES and SET @OLD_... = @@... lines of a
+// mysqldump header
+func createSetVariableStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SET"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementSetSession
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createExecuteImmediateStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "EXECUTE"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens:  []AcceptToken{{Type: "keyword", Value: "IMMEDIATE"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementExecuteImmediate
+				statement.Type = &statementType
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createGrantStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "GRANT"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementGrant
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createRevokeStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "REVOKE"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementRevoke
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createSetPasswordStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SET"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens:  []AcceptToken{{Type: "keyword", Value: "PASSWORD"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementSetPassword
+				statement.Type = &statementType
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createShowStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SHOW"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens: []AcceptToken{
+					{Type: "keyword", Value: "DATABASES"}, {Type: "keyword", Value: "DATABASE"},
+					{Type: "keyword", Value: "KEYS"}, {Type: "keyword", Value: "INDEX"},
+					{Type: "keyword", Value: "COLUMNS"}, {Type: "keyword", Value: "TABLES"},
+					{Type: "keyword", Value: "TABLE"}, {Type: "keyword", Value: "BINARY"},
+					{Type: "keyword", Value: "BINLOG"}, {Type: "keyword", Value: "CHARACTER"},
+					{Type: "keyword", Value: "COLLATION"}, {Type: "keyword", Value: "CREATE"},
+					{Type: "keyword", Value: "ENGINE"}, {Type: "keyword", Value: "ENGINES"},
+					{Type: "keyword", Value: "ERRORS"}, {Type: "keyword", Value: "EVENTS"},
+					{Type: "keyword", Value: "FUNCTION"}, {Type: "keyword", Value: "GRANTS"},
+					{Type: "keyword", Value: "MASTER"}, {Type: "keyword", Value: "OPEN"},
+					{Type: "keyword", Value: "PLUGINS"}, {Type: "keyword", Value: "PRIVILEGES"},
+					{Type: "keyword", Value: "PROCEDURE"}, {Type: "keyword", Value: "PROCESSLIST"},
+					{Type: "keyword", Value: "PROFILE"}, {Type: "keyword", Value: "PROFILES"},
+					{Type: "keyword", Value: "RELAYLOG"}, {Type: "keyword", Value: "REPLICAS"},
 					{Type: "keyword", Value: "REPLICA"}, {Type: "keyword", Value: "SLAVE"},
 					{Type: "keyword", Value: "STATUS"}, {Type: "keyword", Value: "TRIGGERS"},
 					{Type: "keyword", Value: "VARIABLES"}, {Type: "keyword", Value: "WARNINGS"},
@@ -650,6 +1657,205 @@ func createShowStatementParser(options ParseOptions) StatementParser {
 			PostCanGoToNext: func(token *Token) bool { return true },
 		},
 	}
+	if options.Dialect == DialectCockroachDB {
+		steps[1].Validation.AcceptTokens = append(steps[1].Validation.AcceptTokens, AcceptToken{Type: "keyword", Value: "RANGES"})
+	}
+	if options.Dialect == DialectMariaDB {
+		steps[1].Validation.AcceptTokens = append(steps[1].Validation.AcceptTokens, AcceptToken{Type: "keyword", Value: "EXPLAIN"})
+	}
+	if options.Dialect == DialectTrino {
+		steps[1].Validation.AcceptTokens = append(steps[1].Validation.AcceptTokens,
+			AcceptToken{Type: "keyword", Value: "CATALOGS"},
+			AcceptToken{Type: "keyword", Value: "SCHEMAS"},
+			AcceptToken{Type: "keyword", Value: "SESSION"},
+			AcceptToken{Type: "keyword", Value: "STATS"},
+			AcceptToken{Type: "keyword", Value: "FUNCTIONS"},
+		)
+	}
+	if options.Dialect == DialectClickHouse {
+		steps[1].Validation.AcceptTokens = append(steps[1].Validation.AcceptTokens,
+			AcceptToken{Type: "keyword", Value: "DICTIONARIES"},
+			AcceptToken{Type: "keyword", Value: "CLUSTERS"},
+			AcceptToken{Type: "keyword", Value: "CLUSTER"},
+			AcceptToken{Type: "keyword", Value: "SETTINGS"},
+			AcceptToken{Type: "keyword", Value: "USERS"},
+			AcceptToken{Type: "keyword", Value: "ROLES"},
+			AcceptToken{Type: "keyword", Value: "FUNCTIONS"},
+		)
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+// reports whether a BEGIN followed by nextToken starts a transaction rather than a block
+func isTransactionBegin(nextToken Token, dialect Dialect) bool {
+	nextValue := strings.ToUpper(nextToken.Value)
+	switch nextValue {
+	case "TRANSACTION", "WORK":
+		return true
+	case "TRAN", "DISTRIBUTED":
+		return dialect == DialectMSSQL
+	case "DEFERRED", "IMMEDIATE", "EXCLUSIVE":
+		return dialect == DialectSQLite
+	case "ISOLATION", "READ":
+		return baseDialect(dialect) == DialectPSQL
+	}
+
+	// a bare BEGIN is a transaction everywhere except in dialects where it always opens a block
+	isBare := nextToken.Type == TokenSemicolon || nextValue == ""
+	return isBare && dialect != DialectOracle && dialect != DialectMSSQL
+}
+
+func createBeginTransactionStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "BEGIN"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementBeginTransaction
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createStartTransactionStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "START"}},
+			},
+			Add: func(token Token) {
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				RequireBefore: []string{string(TokenWhitespace)},
+				AcceptTokens:  []AcceptToken{{Type: "keyword", Value: "TRANSACTION"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementBeginTransaction
+				statement.Type = &statementType
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createCommitStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "COMMIT"}, {Type: "keyword", Value: "END"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementCommit
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createRollbackStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	// an MSSQL name after TRAN or TRANSACTION is the savepoint to roll back to
+	afterTransaction := false
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "ROLLBACK"}, {Type: "keyword", Value: "ABORT"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementRollback
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+		{
+			// ROLLBACK [WORK | TRANSACTION] TO [SAVEPOINT] name, or MSSQL ROLLBACK TRAN[SACTION] name
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Add: func(token Token) {
+				upperValue := strings.ToUpper(token.Value)
+				if upperValue == "TO" || (afterTransaction && baseDialect(options.Dialect) == DialectMSSQL) {
+					statementType := StatementRollbackToSavepoint
+					statement.Type = &statementType
+				}
+				afterTransaction = upperValue == "TRANSACTION" || upperValue == "TRAN"
+			},
+			PostCanGoToNext: func(token *Token) bool {
+				upperValue := strings.ToUpper(token.Value)
+				return upperValue != "WORK" && upperValue != "TRANSACTION" && upperValue != "TRAN"
+			},
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createSavepointStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "SAVEPOINT"}, {Type: "keyword", Value: "SAVE"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementSavepoint
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
+	return stateMachineStatementParser(statement, steps, options)
+}
+
+func createReleaseSavepointStatementParser(options ParseOptions) StatementParser {
+	statement := createInitialStatement()
+	steps := []Step{
+		{
+			PreCanGoToNext: func(token *Token) bool { return false },
+			Validation: &StepValidation{
+				AcceptTokens: []AcceptToken{{Type: "keyword", Value: "RELEASE"}},
+			},
+			Add: func(token Token) {
+				statementType := StatementReleaseSavepoint
+				statement.Type = &statementType
+				if statement.Start < 0 {
+					statement.Start = token.Start
+				}
+			},
+			PostCanGoToNext: func(token *Token) bool { return true },
+		},
+	}
 	return stateMachineStatementParser(statement, steps, options)
 }
 
@@ -681,6 +1887,7 @@ type stateMachineParser struct {
 	lastBlockOpener        *Token
 	anonBlockStarted       bool
 	openBlocks             int
+	tables                 *tableReader
 }
 
 func (p *stateMachineParser) GetStatement() *Statement {
@@ -708,23 +1915,52 @@ func (p *stateMachineParser) isValidToken(step Step, token Token) bool {
 	return false
 }
 
-func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
+func (p *stateMachineParser) AddToken(token Token, nextToken Token) error {
+	if err := p.addToken(token, nextToken); err != nil {
+		return err
+	}
+
+	// a statement whose CTEs modify data modifies data, whatever its own type
+	if len(p.statement.ModifyingCTEs) > 0 && p.statement.ExecutionType != nil && *p.statement.ExecutionType != ExecutionModification {
+		execType := ExecutionModification
+		p.statement.ExecutionType = &execType
+	}
+
+	// tables are identified once the steps have run, so the keywords that introduce them are read
+	// knowing the type of the statement
+	if p.options.IdentifyTables && p.statement.Type != nil {
+		if !p.tables.addToken(token, nextToken) {
+			if keyword, ok := findTableKeyword(*p.statement.Type, token, nextToken, p.tables.found, p.tables.setSeen); ok {
+				p.tables.expect(keyword)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *stateMachineParser) addToken(token Token, nextToken Token) error {
 	if p.statement.EndStatement != nil {
-		panic("This statement has already got to the end.")
+		return &ParseError{
+			Message: "This statement has already got to the end.",
+			Token:   token,
+			Step:    p.currentStepIndex,
+		}
 	}
 
+	// in a dialect without blocks every statement ends at its semicolon
 	statementTypeEnds := false
-	if p.statement.Type != nil {
+	if p.statement.Type != nil && len(blockOpeners[baseDialect(p.options.Dialect)]) > 0 {
 		if slices.Contains(statementsWithEnds, *p.statement.Type) {
 			statementTypeEnds = true
 		}
 	}
 
-	if token.Type == TokenSemicolon &&
+	endedByTerminator := p.statement.Type != nil && slices.Contains(statementsEndedByTerminator, *p.statement.Type)
+	if token.Type == TokenSemicolon && !endedByTerminator &&
 		(!statementTypeEnds || (p.openBlocks == 0 && (*p.statement.Type == StatementUnknown || (p.statement.CanEnd != nil && *p.statement.CanEnd)))) {
 		end := ";"
 		p.statement.EndStatement = &end
-		return
+		return nil
 	}
 
 	if p.openBlocks > 0 && strings.ToUpper(token.Value) == "END" {
@@ -734,38 +1970,36 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 			p.statement.CanEnd = &canEnd
 		}
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if token.Type == TokenWhitespace {
 		p.setPrevToken(token)
-		return
+		return nil
+	}
+
+	// the markers of a mysql executable comment are left out, so its contents read as part of the statement
+	if token.Type == TokenExecutableComment {
+		return nil
 	}
 
 	if token.Type == TokenKeyword {
 		upperVal := strings.ToUpper(token.Value)
-		isBlockOpener := slices.Contains(blockOpeners[p.options.Dialect], upperVal)
+		isBlockOpener := slices.Contains(blockOpeners[baseDialect(p.options.Dialect)], upperVal)
 		if isBlockOpener && (p.prevNonWhitespaceToken == nil || strings.ToUpper(p.prevNonWhitespaceToken.Value) != "END") {
 			canOpenBlock := false
 			if upperVal != "BEGIN" {
 				canOpenBlock = true
 			} else {
-				if strings.ToUpper(nextToken.Value) != "TRANSACTION" {
-					if p.options.Dialect != DialectSQLite {
-						canOpenBlock = true
-					} else {
-						if !(strings.ToUpper(nextToken.Value) == "DEFERRED" || strings.ToUpper(nextToken.Value) == "IMMEDIATE" || strings.ToUpper(nextToken.Value) == "EXCLUSIVE") {
-							canOpenBlock = true
-						}
-					}
-				}
+				canOpenBlock = !isTransactionBegin(nextToken, p.options.Dialect)
 			}
 
 			if canOpenBlock {
-				if p.options.Dialect == DialectOracle && p.lastBlockOpener != nil && p.lastBlockOpener.Value == "DECLARE" && upperVal == "BEGIN" {
+				isDeclareBlock := p.options.Dialect == DialectOracle || p.options.Dialect == DialectSnowflake
+				if isDeclareBlock && p.lastBlockOpener != nil && strings.ToUpper(p.lastBlockOpener.Value) == "DECLARE" && upperVal == "BEGIN" {
 					p.setPrevToken(token)
 					p.lastBlockOpener = &token
-					return
+					return nil
 				}
 				p.openBlocks++
 				p.lastBlockOpener = &token
@@ -773,18 +2007,56 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 				if p.statement.Type != nil && *p.statement.Type == StatementAnonBlock && !p.anonBlockStarted {
 					p.anonBlockStarted = true
 				} else if p.statement.Type != nil {
-					return
+					return nil
 				}
 			}
 		}
 	}
 
-	if p.options.IdentifyTables && preTableKeywords.MatchString(token.Value) && (p.statement.IsCte == nil || !*p.statement.IsCte) {
-		if p.statement.Type != nil && (*p.statement.Type == StatementSelect || *p.statement.Type == StatementInsert) {
-			tableValue := nextToken.Value
-			if !slices.Contains(p.statement.Tables, tableValue) {
-				p.statement.Tables = append(p.statement.Tables, tableValue)
+	// psql changes object ownership through the ALTER statement of each object type
+	if baseDialect(p.options.Dialect) == DialectPSQL && p.statement.Type != nil && strings.HasPrefix(string(*p.statement.Type), "ALTER_") &&
+		strings.ToUpper(token.Value) == "OWNER" && strings.ToUpper(nextToken.Value) == "TO" {
+		statementType := StatementAlterOwner
+		execType := ExecutionTypes[statementType]
+		p.statement.Type = &statementType
+		p.statement.ExecutionType = &execType
+	}
+
+	// INSERT ... ON CONFLICT and ON DUPLICATE KEY UPDATE update the rows that already exist
+	if p.statement.Type != nil && *p.statement.Type == StatementInsert && strings.ToUpper(token.Value) == "ON" {
+		nextValue := strings.ToUpper(nextToken.Value)
+		if nextValue == "CONFLICT" || nextValue == "DUPLICATE" {
+			p.statement.Upsert = true
+		}
+	}
+
+	// a body given as a dollar-quoted string holds all of its blocks, so the statement can end after it
+	if p.statement.Type != nil && slices.Contains(statementsWithEnds, *p.statement.Type) && p.openBlocks == 0 &&
+		token.Type == TokenString && strings.HasPrefix(token.Value, "$") {
+		canEnd := true
+		p.statement.CanEnd = &canEnd
+	}
+
+	// clickhouse mutations change the rows of the altered table rather than its definition
+	if p.options.Dialect == DialectClickHouse && p.statement.Type != nil && *p.statement.Type == StatementAlterTable {
+		upperValue := strings.ToUpper(token.Value)
+		if upperValue == "UPDATE" || (upperValue == "DELETE" && strings.ToUpper(nextToken.Value) == "WHERE") {
+			replaceTableAccess(p.statement.TableRefs, AccessDDL, AccessWrite)
+		}
+	}
+
+	// COPY ... TO exports the rows of its table or query, COPY ... FROM stdin is followed by the rows it loads.
+	// Only an export to STDOUT is a listing; TO PROGRAM runs a command and TO 'file' writes a file on the server
+	if p.statement.Type != nil && *p.statement.Type == StatementCopy {
+		switch strings.ToUpper(token.Value) {
+		case "TO":
+			if strings.ToUpper(nextToken.Value) == "STDOUT" {
+				execType := ExecutionListing
+				p.statement.ExecutionType = &execType
 			}
+			replaceTableAccess(p.statement.TableRefs, AccessWrite, AccessRead)
+		case "STDIN":
+			p.statement.copyFromStdin = p.prevNonWhitespaceToken != nil && strings.ToUpper(p.prevNonWhitespaceToken.Value) == "FROM"
 		}
 	}
 
@@ -794,22 +2066,22 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 		}
 	}
 
-	if p.statement.Type != nil && p.statement.Start >= 0 {
+	if p.statement.Type != nil && p.statement.Start >= 0 && p.currentStepIndex >= len(p.steps) {
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	upperValue := strings.ToUpper(token.Value)
 	if upperValue == "UNIQUE" ||
-		(p.options.Dialect == DialectMySQL && (upperValue == "FULLTEXT" || upperValue == "SPATIAL")) ||
+		(baseDialect(p.options.Dialect) == DialectMySQL && (upperValue == "FULLTEXT" || upperValue == "SPATIAL")) ||
 		(p.options.Dialect == DialectMSSQL && (upperValue == "CLUSTERED" || upperValue == "NONCLUSTERED")) {
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
-	if (p.options.Dialect == DialectPSQL || p.options.Dialect == DialectMSSQL || p.options.Dialect == DialectBigQuery) && upperValue == "MATERIALIZED" {
+	if (baseDialect(p.options.Dialect) == DialectPSQL || p.options.Dialect == DialectMSSQL || p.options.Dialect == DialectBigQuery) && upperValue == "MATERIALIZED" {
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if p.options.Dialect != DialectSQLite {
@@ -823,75 +2095,76 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 
 		if upperValue == "OR" || (prevIsOr && isAlterOrReplace) {
 			p.setPrevToken(token)
-			return
+			return nil
 		}
 	}
 
-	if (p.options.Dialect == DialectPSQL && (upperValue == "TEMP" || upperValue == "TEMPORARY")) ||
+	if (baseDialect(p.options.Dialect) == DialectPSQL && (upperValue == "TEMP" || upperValue == "TEMPORARY")) ||
+		(p.options.Dialect == DialectSnowflake && (upperValue == "TEMP" || upperValue == "TEMPORARY" || upperValue == "TRANSIENT" || upperValue == "VOLATILE")) ||
 		(p.options.Dialect == DialectSQLite && (upperValue == "TEMP" || upperValue == "TEMPORARY" || upperValue == "VIRTUAL")) {
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
-	if p.options.Dialect == DialectMySQL && upperValue == "DEFINER" {
+	if baseDialect(p.options.Dialect) == DialectMySQL && upperValue == "DEFINER" {
 		definer := 0
 		p.statement.Definer = &definer
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if p.statement.Definer != nil && *p.statement.Definer == 0 && token.Value == "=" {
 		*p.statement.Definer++
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if p.statement.Definer != nil && *p.statement.Definer > 0 {
 		if *p.statement.Definer == 1 && p.prevToken != nil && p.prevToken.Type == TokenWhitespace {
 			*p.statement.Definer++
 			p.setPrevToken(token)
-			return
+			return nil
 		}
 		if *p.statement.Definer > 1 && p.prevToken != nil && p.prevToken.Type != TokenWhitespace {
 			p.setPrevToken(token)
-			return
+			return nil
 		}
 		p.statement.Definer = nil
 	}
 
-	if p.options.Dialect == DialectMySQL && upperValue == "ALGORITHM" {
+	if baseDialect(p.options.Dialect) == DialectMySQL && upperValue == "ALGORITHM" {
 		algorithm := 0
 		p.statement.Algorithm = &algorithm
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if p.statement.Algorithm != nil && *p.statement.Algorithm == 0 && token.Value == "=" {
 		*p.statement.Algorithm++
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if p.statement.Algorithm != nil && *p.statement.Algorithm > 0 {
 		if *p.statement.Algorithm == 1 && p.prevToken != nil && p.prevToken.Type == TokenWhitespace {
 			*p.statement.Algorithm++
 			p.setPrevToken(token)
-			return
+			return nil
 		}
 		if p.statement.Algorithm != nil && *p.statement.Algorithm > 1 && p.prevToken != nil {
 			if slices.Contains([]string{"UNDEFINED", "MERGE", "TEMPTABLE"}, strings.ToUpper(p.prevToken.Value)) {
 				p.setPrevToken(token)
-				return
+				return nil
 			}
 		}
 		p.statement.Algorithm = nil
 	}
 
-	if p.options.Dialect == DialectMySQL && upperValue == "SQL" {
+	if baseDialect(p.options.Dialect) == DialectMySQL && upperValue == "SQL" {
 		sqlSecurity := 0
 		p.statement.SQLSecurity = &sqlSecurity
 		p.setPrevToken(token)
-		return
+		return nil
 	}
 
 	if p.statement.SQLSecurity != nil {
@@ -899,7 +2172,7 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 			(*p.statement.SQLSecurity == 1 && (upperValue == "DEFINER" || upperValue == "INVOKER")) {
 			*p.statement.SQLSecurity++
 			p.setPrevToken(token)
-			return
+			return nil
 		} else if *p.statement.SQLSecurity == 2 {
 			p.statement.SQLSecurity = nil
 		}
@@ -914,18 +2187,34 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 	if p.prevToken != nil && currentStep.Validation != nil && len(currentStep.Validation.RequireBefore) > 0 {
 		if !slices.Contains(currentStep.Validation.RequireBefore, string(p.prevToken.Type)) {
 			requiredTokenTypes := strings.Join(currentStep.Validation.RequireBefore, " or ")
-			panic(fmt.Sprintf("Expected any of these tokens %s before \"%s\" (currentStep=%d).", requiredTokenTypes, token.Value, p.currentStepIndex))
+			var expected []AcceptToken
+			for _, tokenType := range currentStep.Validation.RequireBefore {
+				expected = append(expected, AcceptToken{Type: tokenType})
+			}
+			return &ParseError{
+				Message:  fmt.Sprintf("Expected any of these tokens %s before \"%s\" (currentStep=%d).", requiredTokenTypes, token.Value, p.currentStepIndex),
+				Token:    token,
+				Step:     p.currentStepIndex,
+				Expected: expected,
+			}
 		}
 	}
 
 	if !p.isValidToken(currentStep, token) && p.options.IsStrict {
+		var expected []AcceptToken
 		var expectedTokenStrings []string
 		if currentStep.Validation != nil {
+			expected = currentStep.Validation.AcceptTokens
 			for _, accept := range currentStep.Validation.AcceptTokens {
 				expectedTokenStrings = append(expectedTokenStrings, fmt.Sprintf("(type=\"%s\" value=\"%s\")", accept.Type, accept.Value))
 			}
 		}
-		panic(fmt.Sprintf("Expected any of these tokens %s instead of type=\"%s\" value=\"%s\" (currentStep=%d).", strings.Join(expectedTokenStrings, " or "), token.Type, token.Value, p.currentStepIndex))
+		return &ParseError{
+			Message:  fmt.Sprintf("Expected any of these tokens %s instead of type=\"%s\" value=\"%s\" (currentStep=%d).", strings.Join(expectedTokenStrings, " or "), token.Type, token.Value, p.currentStepIndex),
+			Token:    token,
+			Step:     p.currentStepIndex,
+			Expected: expected,
+		}
 	}
 
 	currentStep.Add(token)
@@ -948,6 +2237,7 @@ func (p *stateMachineParser) AddToken(token Token, nextToken Token) {
 	}
 
 	p.setPrevToken(token)
+	return nil
 }
 
 func stateMachineStatementParser(statement *Statement, steps []Step, options ParseOptions) StatementParser {
@@ -955,16 +2245,29 @@ func stateMachineStatementParser(statement *Statement, steps []Step, options Par
 		statement: statement,
 		steps:     steps,
 		options:   options,
+		tables:    newTableReader(statement, options.Dialect),
 	}
 }
 
 // returns the default parameter types for a given SQL dialect
 func DefaultParamTypesFor(dialect Dialect) *ParamTypes {
-	switch dialect {
+	switch baseDialect(dialect) {
 	case DialectPSQL:
 		return &ParamTypes{Numbered: []rune{'$'}}
 	case DialectMSSQL:
 		return &ParamTypes{Named: []rune{':'}}
+	case DialectDuckDB:
+		positional := true
+		return &ParamTypes{Positional: &positional, Numbered: []rune{'$'}, Named: []rune{'$'}}
+	case DialectSparkSQL:
+		positional := true
+		return &ParamTypes{Positional: &positional, Named: []rune{'$'}}
+	case DialectClickHouse:
+		braced := true
+		return &ParamTypes{Braced: &braced}
+	case DialectSnowflake:
+		positional := true
+		return &ParamTypes{Positional: &positional, Numbered: []rune{':'}, Named: []rune{':'}}
 	case DialectBigQuery:
 		positional := true
 		return &ParamTypes{Positional: &positional, Named: []rune{'@'}, Quoted: []rune{'@'}}
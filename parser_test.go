@@ -1,66 +1,20 @@
 package sqlqueryidentifier
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
 
-// merges consecutive 'unknown', 'whitespace', and 'string' tokens
-// into a single 'unknown' token
-func aggregateUnknownTokens(tokens []Token) []Token {
-	if len(tokens) == 0 {
-		return []Token{}
+func mustParse(t *testing.T, input string, isStrict bool, dialect Dialect, identifyTables bool, paramTypes *ParamTypes) *ParseResult {
+	t.Helper()
+	result, err := Parse(input, isStrict, dialect, identifyTables, paramTypes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
-
-	var result []Token
-
-	for i, token := range tokens {
-		if len(result) == 0 {
-			result = append(result, token)
-			continue
-		}
-
-		prev := &result[len(result)-1]
-		var next *Token
-		if i+1 < len(tokens) {
-			next = &tokens[i+1]
-		}
-
-		isCurrUnknown := token.Type == "unknown"
-		isCurrWhitespace := token.Type == "whitespace"
-		isCurrString := token.Type == "string"
-		isPrevUnknown := prev.Type == "unknown"
-		isNextUnknown := next != nil && next.Type == "unknown"
-
-		isCurrWhitespaceAfterUnknown := isCurrWhitespace && isPrevUnknown
-		isCurrWhitespaceBeforeUnknown := isCurrWhitespace && isNextUnknown
-		isCurrStringAfterUnknown := isCurrString && isPrevUnknown
-		isCurrStringBeforeUnknown := isCurrString && isNextUnknown
-
-		isKnowTokenBeforeUnknown :=
-			(isCurrWhitespaceBeforeUnknown || isCurrStringBeforeUnknown) && !isPrevUnknown
-
-		isNewToken :=
-			isKnowTokenBeforeUnknown ||
-				(!isCurrWhitespaceAfterUnknown &&
-					!isCurrStringAfterUnknown &&
-					(!isCurrUnknown || !isPrevUnknown))
-
-		if isNewToken {
-			newToken := token
-			if isCurrWhitespaceBeforeUnknown {
-				newToken.Type = "unknown"
-			}
-			result = append(result, newToken)
-			continue
-		}
-
-		prev.End = token.End
-		prev.Value += token.Value
-	}
-
 	return result
 }
 
@@ -85,28 +39,56 @@ func TestParse(t *testing.T) {
 
 	t.Run("Single Statements", func(t *testing.T) {
 		t.Run("given is a not recognized statement", func(t *testing.T) {
-			t.Run("should throw an error including the unknown statement", func(t *testing.T) {
-				defer func() {
-					if r := recover(); r == nil {
-						t.Errorf("The code did not panic but was expected to")
-					} else {
-						err, ok := r.(string)
-						if !ok {
-							t.Errorf("Expected panic with string, got %T", r)
-							return
-						}
-						expectedError := `Invalid statement parser "LIST"`
-						if err != expectedError {
-							t.Errorf("Expected panic with message %q, got %q", expectedError, err)
-						}
-					}
-				}()
-				Parse("LIST * FROM Persons", true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+			t.Run("should return an error including the unknown statement", func(t *testing.T) {
+				_, err := Parse("LIST * FROM Persons", true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("Expected a *ParseError, got %T (%v)", err, err)
+				}
+				expectedError := `Invalid statement parser "LIST"`
+				if parseErr.Error() != expectedError {
+					t.Errorf("Expected error message %q, got %q", expectedError, parseErr.Error())
+				}
+				if parseErr.Token.Value != "LIST" || parseErr.Offset != 0 || parseErr.Line != 1 || parseErr.Column != 1 {
+					t.Errorf("Expected error at token LIST on 1:1, got %q at offset %d on %d:%d", parseErr.Token.Value, parseErr.Offset, parseErr.Line, parseErr.Column)
+				}
+			})
+
+			t.Run("should locate the token that failed validation", func(t *testing.T) {
+				query := "SELECT 1;\n  CREATE TABLES foo;"
+				_, err := Parse(query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("Expected a *ParseError, got %T (%v)", err, err)
+				}
+				expected := ParseError{
+					Token:          Token{Type: TokenKeyword, Value: "TABLES", Start: 19, End: 24},
+					Offset:         19,
+					ByteOffset:     19,
+					Line:           2,
+					Column:         10,
+					StatementIndex: 1,
+					Step:           1,
+				}
+				if parseErr.Token != withPosition(query, expected.Token) {
+					t.Errorf("Expected token %+v, got %+v", expected.Token, parseErr.Token)
+				}
+				if parseErr.Offset != expected.Offset || parseErr.ByteOffset != expected.ByteOffset ||
+					parseErr.Line != expected.Line || parseErr.Column != expected.Column {
+					t.Errorf("Expected position %d/%d %d:%d, got %d/%d %d:%d", expected.Offset, expected.ByteOffset, expected.Line, expected.Column,
+						parseErr.Offset, parseErr.ByteOffset, parseErr.Line, parseErr.Column)
+				}
+				if parseErr.StatementIndex != expected.StatementIndex || parseErr.Step != expected.Step {
+					t.Errorf("Expected statement %d step %d, got statement %d step %d", expected.StatementIndex, expected.Step, parseErr.StatementIndex, parseErr.Step)
+				}
+				if !slices.Contains(parseErr.Expected, AcceptToken{Type: "keyword", Value: "TABLE"}) {
+					t.Errorf("Expected the accepted tokens to include TABLE, got %+v", parseErr.Expected)
+				}
 			})
 
 			t.Run("with strict disabled", func(t *testing.T) {
 				t.Run("should parse if first token is unknown", func(t *testing.T) {
-					actual := Parse("LIST * FROM foo", false, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+					actual := mustParse(t, "LIST * FROM foo", false, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 					expected := &ParseResult{
 						Type:  "QUERY",
 						Start: 0,
@@ -126,7 +108,7 @@ func TestParse(t *testing.T) {
 				})
 
 				t.Run("should parse if first token is invalid keyword", func(t *testing.T) {
-					actual := Parse("AS bar LEFT JOIN foo", false, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+					actual := mustParse(t, "AS bar LEFT JOIN foo", false, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 					expected := &ParseResult{
 						Type:  "QUERY",
 						Start: 0,
@@ -149,7 +131,7 @@ func TestParse(t *testing.T) {
 
 		t.Run("given queries with a single statement", func(t *testing.T) {
 			t.Run("should parse \"SELECT\" statement", func(t *testing.T) {
-				actual := Parse("SELECT * FROM Persons", true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, "SELECT * FROM Persons", true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -161,6 +143,7 @@ func TestParse(t *testing.T) {
 							Start:         0,
 							End:           20,
 							Tables:        []string{"Persons"},
+							TableRefs:     []TableRef{{Name: "Persons", Access: AccessRead}},
 							Parameters:    []string{},
 						},
 					},
@@ -169,7 +152,7 @@ func TestParse(t *testing.T) {
 			})
 
 			t.Run("should parse \"select\" statement", func(t *testing.T) {
-				actual := Parse("select * FROM Persons", true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, "select * FROM Persons", true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -181,6 +164,7 @@ func TestParse(t *testing.T) {
 							Start:         0,
 							End:           20,
 							Tables:        []string{"Persons"},
+							TableRefs:     []TableRef{{Name: "Persons", Access: AccessRead}},
 							Parameters:    []string{},
 						},
 					},
@@ -189,7 +173,7 @@ func TestParse(t *testing.T) {
 			})
 
 			t.Run("should parse \"CREATE TABLE\" statement", func(t *testing.T) {
-				actual := Parse("CREATE TABLE Persons (PersonID int, Name varchar(255));", true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, "CREATE TABLE Persons (PersonID int, Name varchar(255));", true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -220,7 +204,7 @@ func TestParse(t *testing.T) {
 					} {
 						t.Run(fmt.Sprintf("for %s", dialectInfo.name), func(t *testing.T) {
 							query := fmt.Sprintf("CREATE %s TABLE Persons (PersonID int, Name varchar(255));", tempType)
-							actual := Parse(query, true, dialectInfo.dialect, false, DefaultParamTypesFor(DialectGeneric))
+							actual := mustParse(t, query, true, dialectInfo.dialect, false, DefaultParamTypesFor(DialectGeneric))
 							expected := &ParseResult{
 								Type:  "QUERY",
 								Start: 0,
@@ -245,7 +229,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"CREATE VIRTUAL TABLE\" statement for sqlite", func(t *testing.T) {
 				query := "CREATE VIRTUAL TABLE Persons (PersonID int, Name varchar(255));"
-				actual := Parse(query, true, DialectSQLite, false, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectSQLite, false, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -267,7 +251,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"CREATE DATABASE\" statement", func(t *testing.T) {
 				query := "CREATE DATABASE Profile;"
-				actual := Parse(query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -289,7 +273,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"DROP TABLE\" statement", func(t *testing.T) {
 				query := "DROP TABLE Persons;"
-				actual := Parse(query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -311,7 +295,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"DROP DATABASE\" statement", func(t *testing.T) {
 				query := "DROP DATABASE Profile;"
-				actual := Parse(query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -333,7 +317,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"INSERT\" statement", func(t *testing.T) {
 				query := "INSERT INTO Persons (PersonID, Name) VALUES (1, 'Jack');"
-				actual := Parse(query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -345,6 +329,7 @@ func TestParse(t *testing.T) {
 							Start:         0,
 							End:           55,
 							Tables:        []string{"Persons"},
+							TableRefs:     []TableRef{{Name: "Persons", Access: AccessWrite}},
 							Parameters:    []string{},
 							EndStatement:  func() *string { s := ";"; return &s }(),
 						},
@@ -355,7 +340,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"UPDATE\" statement", func(t *testing.T) {
 				query := "UPDATE Persons SET Name = 'John' WHERE PersonID = 1;"
-				actual := Parse(query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -366,7 +351,8 @@ func TestParse(t *testing.T) {
 							ExecutionType: ExecutionModification,
 							Start:         0,
 							End:           51,
-							Tables:        []string{},
+							Tables:        []string{"Persons"},
+							TableRefs:     []TableRef{{Name: "Persons", Access: AccessWrite}},
 							Parameters:    []string{},
 							EndStatement:  func() *string { s := ";"; return &s }(),
 						},
@@ -377,7 +363,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"DELETE\" statement", func(t *testing.T) {
 				query := "DELETE FROM Persons WHERE PersonID = 1;"
-				actual := Parse(query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -388,7 +374,8 @@ func TestParse(t *testing.T) {
 							ExecutionType: ExecutionModification,
 							Start:         0,
 							End:           38,
-							Tables:        []string{},
+							Tables:        []string{"Persons"},
+							TableRefs:     []TableRef{{Name: "Persons", Access: AccessWrite}},
 							Parameters:    []string{},
 							EndStatement:  func() *string { s := ";"; return &s }(),
 						},
@@ -399,7 +386,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should parse \"TRUNCATE\" statement", func(t *testing.T) {
 				query := "TRUNCATE TABLE Persons;"
-				actual := Parse(query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+				actual := mustParse(t, query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 				expected := &ParseResult{
 					Type:  "QUERY",
 					Start: 0,
@@ -422,30 +409,29 @@ func TestParse(t *testing.T) {
 			t.Run("with parameters", func(t *testing.T) {
 				t.Run("should extract the parameters", func(t *testing.T) {
 					query := "select x from a where x = ?"
-					actual := Parse(query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
-					actual.Tokens = aggregateUnknownTokens(actual.Tokens)
+					actual := mustParse(t, query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 
 					expectedTokens := []Token{
-						{
-							Type:  "keyword",
-							Value: "select",
-							Start: 0,
-							End:   5,
-						},
-						{
-							Type:  "unknown",
-							Value: " x from a where x = ",
-							Start: 6,
-							End:   25,
-						},
-						{
-							Type:  "parameter",
-							Value: "?",
-							Start: 26,
-							End:   26,
-						},
+						{Type: "keyword", Value: "select", Start: 0, End: 5},
+						{Type: "whitespace", Value: " ", Start: 6, End: 6},
+						{Type: "identifier", Value: "x", Start: 7, End: 7},
+						{Type: "whitespace", Value: " ", Start: 8, End: 8},
+						{Type: "keyword", Value: "from", Start: 9, End: 12},
+						{Type: "whitespace", Value: " ", Start: 13, End: 13},
+						{Type: "identifier", Value: "a", Start: 14, End: 14},
+						{Type: "whitespace", Value: " ", Start: 15, End: 15},
+						{Type: "keyword", Value: "where", Start: 16, End: 20},
+						{Type: "whitespace", Value: " ", Start: 21, End: 21},
+						{Type: "identifier", Value: "x", Start: 22, End: 22},
+						{Type: "whitespace", Value: " ", Start: 23, End: 23},
+						{Type: "operator", Value: "=", Start: 24, End: 24},
+						{Type: "whitespace", Value: " ", Start: 25, End: 25},
+						{Type: "parameter", Value: "?", Start: 26, End: 26},
 					}
 
+					for i := range expectedTokens {
+						expectedTokens[i] = withPosition(query, expectedTokens[i])
+					}
 					if !reflect.DeepEqual(actual.Tokens, expectedTokens) {
 						t.Errorf("Expected tokens %#v, but got %#v", expectedTokens, actual.Tokens)
 					}
@@ -458,30 +444,29 @@ func TestParse(t *testing.T) {
 
 				t.Run("should extract PSQL parameters", func(t *testing.T) {
 					query := "select x from a where x = $1"
-					actual := Parse(query, true, DialectPSQL, true, DefaultParamTypesFor(DialectPSQL))
-					actual.Tokens = aggregateUnknownTokens(actual.Tokens)
+					actual := mustParse(t, query, true, DialectPSQL, true, DefaultParamTypesFor(DialectPSQL))
 
 					expectedTokens := []Token{
-						{
-							Type:  "keyword",
-							Value: "select",
-							Start: 0,
-							End:   5,
-						},
-						{
-							Type:  "unknown",
-							Value: " x from a where x = ",
-							Start: 6,
-							End:   25,
-						},
-						{
-							Type:  "parameter",
-							Value: "$1",
-							Start: 26,
-							End:   27,
-						},
+						{Type: "keyword", Value: "select", Start: 0, End: 5},
+						{Type: "whitespace", Value: " ", Start: 6, End: 6},
+						{Type: "identifier", Value: "x", Start: 7, End: 7},
+						{Type: "whitespace", Value: " ", Start: 8, End: 8},
+						{Type: "keyword", Value: "from", Start: 9, End: 12},
+						{Type: "whitespace", Value: " ", Start: 13, End: 13},
+						{Type: "identifier", Value: "a", Start: 14, End: 14},
+						{Type: "whitespace", Value: " ", Start: 15, End: 15},
+						{Type: "keyword", Value: "where", Start: 16, End: 20},
+						{Type: "whitespace", Value: " ", Start: 21, End: 21},
+						{Type: "identifier", Value: "x", Start: 22, End: 22},
+						{Type: "whitespace", Value: " ", Start: 23, End: 23},
+						{Type: "operator", Value: "=", Start: 24, End: 24},
+						{Type: "whitespace", Value: " ", Start: 25, End: 25},
+						{Type: "parameter", Value: "$1", Start: 26, End: 27},
 					}
 
+					for i := range expectedTokens {
+						expectedTokens[i] = withPosition(query, expectedTokens[i])
+					}
 					if !reflect.DeepEqual(actual.Tokens, expectedTokens) {
 						t.Errorf("Expected tokens %#v, but got %#v", expectedTokens, actual.Tokens)
 					}
@@ -494,42 +479,37 @@ func TestParse(t *testing.T) {
 
 				t.Run("should extract multiple PSQL parameters", func(t *testing.T) {
 					query := "select x from a where x = $1 and y = $2"
-					actual := Parse(query, true, DialectPSQL, true, DefaultParamTypesFor(DialectPSQL))
-					actual.Tokens = aggregateUnknownTokens(actual.Tokens)
+					actual := mustParse(t, query, true, DialectPSQL, true, DefaultParamTypesFor(DialectPSQL))
 
 					expectedTokens := []Token{
-						{
-							Type:  "keyword",
-							Value: "select",
-							Start: 0,
-							End:   5,
-						},
-						{
-							Type:  "unknown",
-							Value: " x from a where x = ",
-							Start: 6,
-							End:   25,
-						},
-						{
-							Type:  "parameter",
-							Value: "$1",
-							Start: 26,
-							End:   27,
-						},
-						{
-							Type:  "unknown",
-							Value: " and y = ",
-							Start: 28,
-							End:   36,
-						},
-						{
-							Type:  "parameter",
-							Value: "$2",
-							Start: 37,
-							End:   38,
-						},
+						{Type: "keyword", Value: "select", Start: 0, End: 5},
+						{Type: "whitespace", Value: " ", Start: 6, End: 6},
+						{Type: "identifier", Value: "x", Start: 7, End: 7},
+						{Type: "whitespace", Value: " ", Start: 8, End: 8},
+						{Type: "keyword", Value: "from", Start: 9, End: 12},
+						{Type: "whitespace", Value: " ", Start: 13, End: 13},
+						{Type: "identifier", Value: "a", Start: 14, End: 14},
+						{Type: "whitespace", Value: " ", Start: 15, End: 15},
+						{Type: "keyword", Value: "where", Start: 16, End: 20},
+						{Type: "whitespace", Value: " ", Start: 21, End: 21},
+						{Type: "identifier", Value: "x", Start: 22, End: 22},
+						{Type: "whitespace", Value: " ", Start: 23, End: 23},
+						{Type: "operator", Value: "=", Start: 24, End: 24},
+						{Type: "whitespace", Value: " ", Start: 25, End: 25},
+						{Type: "parameter", Value: "$1", Start: 26, End: 27},
+						{Type: "whitespace", Value: " ", Start: 28, End: 28},
+						{Type: "keyword", Value: "and", Start: 29, End: 31},
+						{Type: "whitespace", Value: " ", Start: 32, End: 32},
+						{Type: "identifier", Value: "y", Start: 33, End: 33},
+						{Type: "whitespace", Value: " ", Start: 34, End: 34},
+						{Type: "operator", Value: "=", Start: 35, End: 35},
+						{Type: "whitespace", Value: " ", Start: 36, End: 36},
+						{Type: "parameter", Value: "$2", Start: 37, End: 38},
 					}
 
+					for i := range expectedTokens {
+						expectedTokens[i] = withPosition(query, expectedTokens[i])
+					}
 					if !reflect.DeepEqual(actual.Tokens, expectedTokens) {
 						t.Errorf("Expected tokens %#v, but got %#v", expectedTokens, actual.Tokens)
 					}
@@ -542,30 +522,29 @@ func TestParse(t *testing.T) {
 
 				t.Run("should extract mssql parameters", func(t *testing.T) {
 					query := "select x from a where x = :foo"
-					actual := Parse(query, true, DialectMSSQL, true, DefaultParamTypesFor(DialectMSSQL))
-					actual.Tokens = aggregateUnknownTokens(actual.Tokens)
+					actual := mustParse(t, query, true, DialectMSSQL, true, DefaultParamTypesFor(DialectMSSQL))
 
 					expectedTokens := []Token{
-						{
-							Type:  "keyword",
-							Value: "select",
-							Start: 0,
-							End:   5,
-						},
-						{
-							Type:  "unknown",
-							Value: " x from a where x = ",
-							Start: 6,
-							End:   25,
-						},
-						{
-							Type:  "parameter",
-							Value: ":foo",
-							Start: 26,
-							End:   29,
-						},
+						{Type: "keyword", Value: "select", Start: 0, End: 5},
+						{Type: "whitespace", Value: " ", Start: 6, End: 6},
+						{Type: "identifier", Value: "x", Start: 7, End: 7},
+						{Type: "whitespace", Value: " ", Start: 8, End: 8},
+						{Type: "keyword", Value: "from", Start: 9, End: 12},
+						{Type: "whitespace", Value: " ", Start: 13, End: 13},
+						{Type: "identifier", Value: "a", Start: 14, End: 14},
+						{Type: "whitespace", Value: " ", Start: 15, End: 15},
+						{Type: "keyword", Value: "where", Start: 16, End: 20},
+						{Type: "whitespace", Value: " ", Start: 21, End: 21},
+						{Type: "identifier", Value: "x", Start: 22, End: 22},
+						{Type: "whitespace", Value: " ", Start: 23, End: 23},
+						{Type: "operator", Value: "=", Start: 24, End: 24},
+						{Type: "whitespace", Value: " ", Start: 25, End: 25},
+						{Type: "parameter", Value: ":foo", Start: 26, End: 29},
 					}
 
+					for i := range expectedTokens {
+						expectedTokens[i] = withPosition(query, expectedTokens[i])
+					}
 					if !reflect.DeepEqual(actual.Tokens, expectedTokens) {
 						t.Errorf("Expected tokens %#v, but got %#v", expectedTokens, actual.Tokens)
 					}
@@ -576,9 +555,16 @@ func TestParse(t *testing.T) {
 					}
 				})
 
+				t.Run("should return an error for an invalid custom parameter", func(t *testing.T) {
+					_, err := Parse("SELECT ( FROM t", true, DialectGeneric, false, &ParamTypes{Custom: []string{"("}})
+					if err == nil || !strings.Contains(err.Error(), "Invalid custom parameter") {
+						t.Errorf("Expected an invalid custom parameter error, but got %v", err)
+					}
+				})
+
 				t.Run("should not identify params in a comment", func(t *testing.T) {
 					query := "-- comment ?"
-					actual := Parse(query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
+					actual := mustParse(t, query, true, DialectGeneric, false, DefaultParamTypesFor(DialectGeneric))
 					expected := &ParseResult{
 						Type:  "QUERY",
 						Start: 0,
@@ -590,7 +576,7 @@ func TestParse(t *testing.T) {
 
 				t.Run("should not identify params in a string", func(t *testing.T) {
 					query := "select '$1'"
-					actual := Parse(query, true, DialectPSQL, true, DefaultParamTypesFor(DialectPSQL))
+					actual := mustParse(t, query, true, DialectPSQL, true, DefaultParamTypesFor(DialectPSQL))
 
 					expectedTokens := []Token{
 						{
@@ -606,13 +592,17 @@ func TestParse(t *testing.T) {
 							End:   6,
 						},
 						{
-							Type:  "string",
-							Value: "'$1'",
-							Start: 7,
-							End:   10,
+							Type:        "string",
+							Value:       "'$1'",
+							Start:       7,
+							End:         10,
+							LiteralKind: LiteralStandard,
 						},
 					}
 
+					for i := range expectedTokens {
+						expectedTokens[i] = withPosition(query, expectedTokens[i])
+					}
 					if !reflect.DeepEqual(actual.Tokens, expectedTokens) {
 						t.Errorf("Expected tokens %#v, but got %#v", expectedTokens, actual.Tokens)
 					}
@@ -621,42 +611,37 @@ func TestParse(t *testing.T) {
 
 				t.Run("should extract multiple mssql parameters", func(t *testing.T) {
 					query := "select x from a where x = :foo and y = :bar"
-					actual := Parse(query, true, DialectMSSQL, true, DefaultParamTypesFor(DialectMSSQL))
-					actual.Tokens = aggregateUnknownTokens(actual.Tokens)
+					actual := mustParse(t, query, true, DialectMSSQL, true, DefaultParamTypesFor(DialectMSSQL))
 
 					expectedTokens := []Token{
-						{
-							Type:  "keyword",
-							Value: "select",
-							Start: 0,
-							End:   5,
-						},
-						{
-							Type:  "unknown",
-							Value: " x from a where x = ",
-							Start: 6,
-							End:   25,
-						},
-						{
-							Type:  "parameter",
-							Value: ":foo",
-							Start: 26,
-							End:   29,
-						},
-						{
-							Type:  "unknown",
-							Value: " and y = ",
-							Start: 30,
-							End:   38,
-						},
-						{
-							Type:  "parameter",
-							Value: ":bar",
-							Start: 39,
-							End:   42,
-						},
+						{Type: "keyword", Value: "select", Start: 0, End: 5},
+						{Type: "whitespace", Value: " ", Start: 6, End: 6},
+						{Type: "identifier", Value: "x", Start: 7, End: 7},
+						{Type: "whitespace", Value: " ", Start: 8, End: 8},
+						{Type: "keyword", Value: "from", Start: 9, End: 12},
+						{Type: "whitespace", Value: " ", Start: 13, End: 13},
+						{Type: "identifier", Value: "a", Start: 14, End: 14},
+						{Type: "whitespace", Value: " ", Start: 15, End: 15},
+						{Type: "keyword", Value: "where", Start: 16, End: 20},
+						{Type: "whitespace", Value: " ", Start: 21, End: 21},
+						{Type: "identifier", Value: "x", Start: 22, End: 22},
+						{Type: "whitespace", Value: " ", Start: 23, End: 23},
+						{Type: "operator", Value: "=", Start: 24, End: 24},
+						{Type: "whitespace", Value: " ", Start: 25, End: 25},
+						{Type: "parameter", Value: ":foo", Start: 26, End: 29},
+						{Type: "whitespace", Value: " ", Start: 30, End: 30},
+						{Type: "keyword", Value: "and", Start: 31, End: 33},
+						{Type: "whitespace", Value: " ", Start: 34, End: 34},
+						{Type: "identifier", Value: "y", Start: 35, End: 35},
+						{Type: "whitespace", Value: " ", Start: 36, End: 36},
+						{Type: "operator", Value: "=", Start: 37, End: 37},
+						{Type: "whitespace", Value: " ", Start: 38, End: 38},
+						{Type: "parameter", Value: ":bar", Start: 39, End: 42},
 					}
 
+					for i := range expectedTokens {
+						expectedTokens[i] = withPosition(query, expectedTokens[i])
+					}
 					if !reflect.DeepEqual(actual.Tokens, expectedTokens) {
 						t.Errorf("Expected tokens %#v, but got %#v", expectedTokens, actual.Tokens)
 					}
@@ -673,7 +658,7 @@ func TestParse(t *testing.T) {
 	t.Run("Multiple Statements", func(t *testing.T) {
 		t.Run("should parse a query with different statements in a single line", func(t *testing.T) {
 			query := "INSERT INTO Persons (PersonID, Name) VALUES (1, 'Jack');SELECT * FROM Persons"
-			actual := Parse(query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+			actual := mustParse(t, query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 			expected := &ParseResult{
 				Type:  "QUERY",
 				Start: 0,
@@ -687,6 +672,7 @@ func TestParse(t *testing.T) {
 						EndStatement:  func() *string { s := ";"; return &s }(),
 						Parameters:    []string{},
 						Tables:        []string{"Persons"},
+						TableRefs:     []TableRef{{Name: "Persons", Access: AccessWrite}},
 					},
 					{
 						Type:          StatementSelect,
@@ -695,6 +681,7 @@ func TestParse(t *testing.T) {
 						End:           76,
 						Parameters:    []string{},
 						Tables:        []string{"Persons"},
+						TableRefs:     []TableRef{{Name: "Persons", Access: AccessRead}},
 					},
 				},
 			}
@@ -703,7 +690,7 @@ func TestParse(t *testing.T) {
 
 		t.Run("should identify a query with different statements in multiple lines", func(t *testing.T) {
 			query := "\n        INSERT INTO Persons (PersonID, Name) VALUES (1, 'Jack');\n        SELECT * FROM Persons';\n      "
-			actual := Parse(query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
+			actual := mustParse(t, query, true, DialectGeneric, true, DefaultParamTypesFor(DialectGeneric))
 			expected := &ParseResult{
 				Type:  "QUERY",
 				Start: 0,
@@ -717,6 +704,7 @@ func TestParse(t *testing.T) {
 						EndStatement:  func() *string { s := ";"; return &s }(),
 						Parameters:    []string{},
 						Tables:        []string{"Persons"},
+						TableRefs:     []TableRef{{Name: "Persons", Access: AccessWrite}},
 					},
 					{
 						Type:          StatementSelect,
@@ -725,6 +713,7 @@ func TestParse(t *testing.T) {
 						End:           103,
 						Parameters:    []string{},
 						Tables:        []string{"Persons"},
+						TableRefs:     []TableRef{{Name: "Persons", Access: AccessRead}},
 					},
 				},
 			}
@@ -783,7 +772,7 @@ func TestParse(t *testing.T) {
 				firstWord := strings.Split(strings.TrimSpace(sql), " ")[0]
 				t.Run(fmt.Sprintf("parses %s structure", firstWord), func(t *testing.T) {
 					query := sql + "\nSELECT 1;"
-					result := Parse(query, false, DialectBigQuery, false, DefaultParamTypesFor(DialectGeneric))
+					result := mustParse(t, query, false, DialectBigQuery, false, DefaultParamTypesFor(DialectGeneric))
 					if len(result.Body) != 2 {
 						t.Fatalf("Expected 2 statements, but got %d", len(result.Body))
 					}
@@ -802,7 +791,7 @@ func TestParse(t *testing.T) {
 		})
 
 		t.Run("parses BEGIN statement as ANON_BLOCK", func(t *testing.T) {
-			result := Parse(`BEGIN SELECT 1; END; SELECT 1;`, false, DialectBigQuery, false, DefaultParamTypesFor(DialectGeneric))
+			result := mustParse(t, `BEGIN SELECT 1; END; SELECT 1;`, false, DialectBigQuery, false, DefaultParamTypesFor(DialectGeneric))
 			if len(result.Body) != 2 {
 				t.Fatalf("Expected 2 statements, got %d", len(result.Body))
 			}
@@ -814,19 +803,35 @@ func TestParse(t *testing.T) {
 			}
 		})
 
-		t.Run("parses BEGIN TRANSACTION as UNKNOWN", func(t *testing.T) {
-			result := Parse(`BEGIN TRANSACTION; SELECT 1; COMMIT;`, false, DialectBigQuery, false, DefaultParamTypesFor(DialectGeneric))
+		t.Run("parses BEGIN TRANSACTION as BEGIN_TRANSACTION", func(t *testing.T) {
+			result := mustParse(t, `BEGIN TRANSACTION; SELECT 1; COMMIT;`, false, DialectBigQuery, false, DefaultParamTypesFor(DialectGeneric))
 			if len(result.Body) != 3 {
 				t.Fatalf("Expected 3 statements, got %d", len(result.Body))
 			}
-			if result.Body[0].Type != StatementUnknown {
-				t.Errorf("Expected first statement to be UNKNOWN, got %s", result.Body[0].Type)
+			if result.Body[0].Type != StatementBeginTransaction {
+				t.Errorf("Expected first statement to be BEGIN_TRANSACTION, got %s", result.Body[0].Type)
 			}
 			if result.Body[1].Type != StatementSelect {
 				t.Errorf("Expected second statement to be SELECT, got %s", result.Body[1].Type)
 			}
-			if result.Body[2].Type != StatementUnknown {
-				t.Errorf("Expected third statement to be UNKNOWN, got %s", result.Body[2].Type)
+			if result.Body[2].Type != StatementCommit {
+				t.Errorf("Expected third statement to be COMMIT, got %s", result.Body[2].Type)
+			}
+		})
+	})
+
+	t.Run("Parser for mssql", func(t *testing.T) {
+		t.Run("should not open a block for BEGIN TRAN inside a procedure", func(t *testing.T) {
+			sql := "CREATE PROCEDURE p AS\nBEGIN\n  BEGIN TRAN;\n  UPDATE t SET x = 1;\n  COMMIT TRAN;\nEND;\nSELECT 1;"
+			result := mustParse(t, sql, true, DialectMSSQL, false, DefaultParamTypesFor(DialectMSSQL))
+			if len(result.Body) != 2 {
+				t.Fatalf("Expected 2 statements, got %d", len(result.Body))
+			}
+			if result.Body[0].Type != StatementCreateProcedure {
+				t.Errorf("Expected first statement to be CREATE_PROCEDURE, got %s", result.Body[0].Type)
+			}
+			if result.Body[1].Type != StatementSelect {
+				t.Errorf("Expected second statement to be SELECT, got %s", result.Body[1].Type)
 			}
 		})
 	})
@@ -835,7 +840,7 @@ func TestParse(t *testing.T) {
 		t.Run("Given a CASE Statement", func(t *testing.T) {
 			t.Run("should parse a simple case statement", func(t *testing.T) {
 				sql := `SELECT CASE WHEN a = 'a' THEN 'foo' ELSE 'bar' END CASE from table;`
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 1 {
 					t.Errorf("Expected 1 statement, got %d", len(result.Body))
 				}
@@ -845,7 +850,7 @@ func TestParse(t *testing.T) {
 		t.Run("given an anonymous block with an OUT param", func(t *testing.T) {
 			t.Run("should treat a simple block as a single query", func(t *testing.T) {
 				sql := "BEGIN\n          SELECT\n            cols.column_name INTO :variable\n          FROM\n            example_table;\n        END"
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 1 {
 					t.Fatalf("Expected 1 statement, got %d", len(result.Body))
 				}
@@ -862,7 +867,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should easily identify two blocks", func(t *testing.T) {
 				sql := "BEGIN\n          SELECT\n            cols.column_name INTO :variable\n          FROM\n            example_table;\n        END;\n\n        BEGIN\n          SELECT\n            cols.column_name INTO :variable\n          FROM\n            example_table;\n        END\n        "
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 
 				if len(result.Body) != 2 {
 					t.Fatalf("Expected 2 statements, got %d", len(result.Body))
@@ -889,7 +894,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should identify a block query and a normal query together", func(t *testing.T) {
 				sql := "BEGIN\n      SELECT\n      cols.column_name INTO :variable\n      FROM\n      example_table;\n      END;\n\n      select * from another_thing\n      "
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 2 {
 					t.Fatalf("Expected 2 statements, got %d", len(result.Body))
 				}
@@ -914,7 +919,7 @@ func TestParse(t *testing.T) {
 		t.Run("given an anonymous block with a variable", func(t *testing.T) {
 			t.Run("should treat a block with DECLARE and another query as two separate queries", func(t *testing.T) {
 				sql := "DECLARE\n          PK_NAME VARCHAR(200);\n        BEGIN\n          SELECT\n            cols.column_name INTO PK_NAME\n          FROM\n            example_table;\n        END;\n\n        select * from foo;\n      "
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 2 {
 					t.Fatalf("Expected 2 statements, got %d", len(result.Body))
 				}
@@ -937,7 +942,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("Should treat a block with two queries as a single query", func(t *testing.T) {
 				sql := "\n        DECLARE\n          PK_NAME VARCHAR(200);\n          FOO integer;\n\n        BEGIN\n          SELECT\n            cols.column_name INTO PK_NAME\n          FROM\n            example_table;\n          SELECT 1 INTO FOO from other_example;\n        END;\n      "
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 1 {
 					t.Errorf("Expected 1 statement, got %d", len(result.Body))
 				}
@@ -945,7 +950,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("Should treat a complex block as a single query", func(t *testing.T) {
 				sql := "        DECLARE\n          PK_NAME VARCHAR(200);\n\n        BEGIN\n          EXECUTE IMMEDIATE ('CREATE SEQUENCE \"untitled_table3_seq\"');\n\n        SELECT\n          cols.column_name INTO PK_NAME\n        FROM\n          all_constraints cons,\n          all_cons_columns cols\n        WHERE\n          cons.constraint_type = 'P'\n          AND cons.constraint_name = cols.constraint_name\n          AND cons.owner = cols.owner\n          AND cols.table_name = 'untitled_table3';\n\n        execute immediate (\n          'create or replace trigger \"untitled_table3_autoinc_trg\"  BEFORE INSERT on \"untitled_table3\"  for each row  declare  checking number := 1;  begin    if (:new.\"' || PK_NAME || '\" is null) then      while checking >= 1 loop        select \"untitled_table3_seq\".nextval into :new.\"' || PK_NAME || '\" from dual;        select count(\"' || PK_NAME || '\") into checking from \"untitled_table3\"        where \"' || PK_NAME || '\" = :new.\"' || PK_NAME || '\";      end loop;    end if;  end;'\n        );\n        END;\n      "
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 1 {
 					t.Errorf("Expected 1 statement, got %d", len(result.Body))
 				}
@@ -953,7 +958,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should identify a compound statement with a nested compound statement as a single statement", func(t *testing.T) {
 				sql := "DECLARE\n          n_emp_id EMPLOYEES.EMPLOYEE_ID%%TYPE := &emp_id1;\n        BEGIN\n          DECLARE\n            n_emp_id employees.employee_id%%TYPE := &emp_id2;\n            v_name   employees.first_name%%TYPE;\n          BEGIN\n            SELECT first_name, CASE foo WHEN 'a' THEN 1 ELSE 2 END CASE as other\n            INTO v_name\n            FROM employees\n            WHERE employee_id = n_emp_id;\n\n            DBMS_OUTPUT.PUT_LINE('First name of employee ' || n_emp_id ||\n                                              ' is ' || v_name);\n            EXCEPTION\n              WHEN no_data_found THEN\n                DBMS_OUTPUT.PUT_LINE('Employee ' || n_emp_id || ' not found');\n          END;\n        END;"
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 1 {
 					t.Errorf("Expected 1 statement, got %d", len(result.Body))
 				}
@@ -961,7 +966,7 @@ func TestParse(t *testing.T) {
 
 			t.Run("should identify a block query after a create table query", func(t *testing.T) {
 				sql := "create table\n          \"untitled_table8\" (\n            \"id\" integer not null primary key,\n            \"created_at\" varchar(255) not null\n          );\n\n        DECLARE\n          PK_NAME VARCHAR(200);\n\n        BEGIN\n          EXECUTE IMMEDIATE ('CREATE SEQUENCE \"untitled_table8_seq\"');\n\n        SELECT\n          cols.column_name INTO PK_NAME\n        FROM\n          all_constraints cons,\n          all_cons_columns cols\n        WHERE\n          cons.constraint_type = 'P'\n          AND cons.constraint_name = cols.constraint_name\n          AND cons.owner = cols.owner\n          AND cols.table_name = 'untitled_table8';\n\n        execute immediate (\n          'create or replace trigger \"untitled_table8_autoinc_trg\"  BEFORE INSERT on \"untitled_table8\"  for each row  declare  checking number := 1;  begin    if (:new.\"' || PK_NAME || '\" is null) then      while checking >= 1 loop        select \"untitled_table8_seq\".nextval into :new.\"' || PK_NAME || '\" from dual;        select count(\"' || PK_NAME || '\") into checking from \"untitled_table8\"        where \"' || PK_NAME || '\" = :new.\"' || PK_NAME || '\";      end loop;    end if;  end;'\n        );\n\n        END;"
-				result := Parse(sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
+				result := mustParse(t, sql, false, DialectOracle, false, DefaultParamTypesFor(DialectGeneric))
 				if len(result.Body) != 2 {
 					t.Fatalf("Expected 2 statements, got %d", len(result.Body))
 				}
@@ -0,0 +1,56 @@
+package sqlqueryidentifier
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// maps rune offsets of an input to byte offsets and 1-based line and column positions
+type sourceIndex struct {
+	byteOffsets []int // byte offset of every rune, plus the length of the input
+	lineStarts  []int // rune offset of the first rune of every line
+}
+
+// the offsets are read from the bytes of the query, so that every invalid UTF-8 byte, which is a single
+// rune of the input, is counted as one byte and not as the three of its replacement character
+func newSourceIndex(query string) *sourceIndex {
+	index := &sourceIndex{
+		byteOffsets: make([]int, 0, utf8.RuneCountInString(query)+1),
+		lineStarts:  []int{0},
+	}
+	for byteOffset, ch := range query {
+		index.byteOffsets = append(index.byteOffsets, byteOffset)
+		if ch == '\n' {
+			index.lineStarts = append(index.lineStarts, len(index.byteOffsets))
+		}
+	}
+	index.byteOffsets = append(index.byteOffsets, len(query))
+	return index
+}
+
+func (s *sourceIndex) clamp(offset int) int {
+	return max(0, min(offset, len(s.byteOffsets)-1))
+}
+
+// returns the byte offset of the first byte of the rune at offset
+func (s *sourceIndex) byteStart(offset int) int {
+	return s.byteOffsets[s.clamp(offset)]
+}
+
+// returns the byte offset of the last byte of the rune at offset
+func (s *sourceIndex) byteEnd(offset int) int {
+	return s.byteOffsets[s.clamp(offset+1)] - 1
+}
+
+func (s *sourceIndex) lineColumn(offset int) (int, int) {
+	offset = s.clamp(offset)
+	line := sort.Search(len(s.lineStarts), func(i int) bool { return s.lineStarts[i] > offset })
+	return line, offset - s.lineStarts[line-1] + 1
+}
+
+func (s *sourceIndex) locateToken(token *Token) {
+	token.StartByte = s.byteStart(token.Start)
+	token.EndByte = max(token.StartByte-1, s.byteEnd(token.End))
+	token.StartLine, token.StartColumn = s.lineColumn(token.Start)
+	token.EndLine, token.EndColumn = s.lineColumn(max(token.Start, token.End))
+}
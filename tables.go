@@ -0,0 +1,490 @@
+package sqlqueryidentifier
+
+import (
+	"slices"
+	"strings"
+)
+
+// clause words that can follow a table name, so can be neither a table name nor its alias
+var clauseWords = map[string]bool{
+	"SELECT": true, "FROM": true, "SET": true, "WITH": true, "AS": true, "TO": true,
+	"WHERE": true, "ON": true, "USING": true, "JOIN": true, "INNER": true, "LEFT": true,
+	"RIGHT": true, "FULL": true, "CROSS": true, "OUTER": true, "NATURAL": true, "STRAIGHT_JOIN": true,
+	"GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "FETCH": true,
+	"UNION": true, "EXCEPT": true, "INTERSECT": true, "MINUS": true, "WINDOW": true, "QUALIFY": true,
+	"VALUES": true, "DEFAULT": true, "OUTPUT": true, "RETURNING": true, "PARTITION": true,
+	"TABLESAMPLE": true, "PIVOT": true, "UNPIVOT": true, "USE": true, "IGNORE": true, "FORCE": true,
+	"CONNECT": true, "OVERRIDING": true, "LATERAL": true, "ONLY": true, "INTO": true,
+}
+
+// clickhouse clause words, which are ordinary table names in the other dialects
+var clickhouseClauseWords = map[string]bool{
+	"FORMAT": true, "SETTINGS": true, "FINAL": true, "SAMPLE": true, "PREWHERE": true, "ARRAY": true,
+	"GLOBAL": true, "ANY": true, "ALL": true, "ASOF": true, "SEMI": true, "ANTI": true,
+}
+
+// keywords that must be followed by a table name, so the word after them is never read as a clause word
+var tableNameKeywords = []string{"FROM", "JOIN", "INTO", "UPDATE"}
+
+// statements about a single table object, with the way each of them uses the table
+var objectStatementAccess = map[StatementType]TableAccess{
+	StatementOptimize:   AccessWrite,
+	StatementAttach:     AccessDDL,
+	StatementDetach:     AccessDDL,
+	StatementExists:     AccessRead,
+	StatementDescribe:   AccessRead,
+	StatementBackup:     AccessRead,
+	StatementRestore:    AccessDDL,
+	StatementVacuum:     AccessWrite,
+	StatementCacheTable: AccessRead,
+	StatementMsckRepair: AccessDDL,
+}
+
+// words that can appear between a table keyword and the table name
+var tableModifiers = []string{
+	"ONLY", "LATERAL", "TABLE", "IF", "NOT", "EXISTS", "LOW_PRIORITY", "HIGH_PRIORITY", "DELAYED", "QUICK", "IGNORE",
+}
+
+// objects other than tables that privileges can be granted on
+var nonTableGrantObjects = []string{
+	"ALL", "DATABASE", "SCHEMA", "FUNCTION", "PROCEDURE", "ROUTINE", "SEQUENCE", "TYPE", "DOMAIN", "LANGUAGE",
+	"FOREIGN", "TABLESPACE", "LARGE", "SERVER",
+}
+
+// describes what can follow a keyword that introduces a table name
+type tableKeyword struct {
+	list     bool // a comma separated list of tables may follow
+	alias    bool // each table may be followed by an alias
+	required bool // a table name must follow
+	access   TableAccess
+}
+
+func (k tableKeyword) as(access TableAccess) tableKeyword {
+	k.access = access
+	return k
+}
+
+var (
+	tableSource = tableKeyword{list: true, alias: true}
+	tableJoin   = tableKeyword{alias: true}
+	tableObject = tableKeyword{}
+	tableList   = tableKeyword{list: true}
+)
+
+// returns whether the token is followed by the name of a table for the given statement type. found is
+// the number of tables already identified in the statement, as some keywords only introduce the first one,
+// and setSeen tells whether the SET of an UPDATE has been read, which ends the list of updated tables
+func findTableKeyword(statementType StatementType, token Token, nextToken Token, found int, setSeen bool) (tableKeyword, bool) {
+	if token.Type != TokenKeyword && token.Type != TokenIdentifier {
+		return tableKeyword{}, false
+	}
+	keyword, ok := findStatementTableKeyword(statementType, token, nextToken, found, setSeen)
+	keyword.required = ok && slices.Contains(tableNameKeywords, strings.ToUpper(token.Value))
+	return keyword, ok
+}
+
+func findStatementTableKeyword(statementType StatementType, token Token, nextToken Token, found int, setSeen bool) (tableKeyword, bool) {
+	keyword := strings.ToUpper(token.Value)
+	switch statementType {
+	case StatementSelect:
+		switch keyword {
+		case "PIVOT", "UNPIVOT":
+			// duckdb PIVOT and UNPIVOT statements start with the table they reshape
+			if found == 0 {
+				return tableObject.as(AccessRead), true
+			}
+		case "FROM":
+			return tableSource.as(AccessRead), true
+		case "JOIN":
+			return tableJoin.as(AccessRead), true
+		case "INTO":
+			return tableJoin.as(AccessWrite), true
+		}
+	case StatementInsert, StatementReplace:
+		switch keyword {
+		case "INTO", "OVERWRITE":
+			return tableJoin.as(AccessWrite), true
+		case "FROM":
+			return tableSource.as(AccessRead), true
+		case "JOIN":
+			return tableJoin.as(AccessRead), true
+		}
+	case StatementUpdate:
+		switch keyword {
+		case "UPDATE":
+			return tableSource.as(AccessWrite), true
+		case "FROM":
+			return tableSource.as(AccessRead), true
+		case "JOIN":
+			// the tables joined before SET in a mysql multi-table update can be written like the others
+			if !setSeen {
+				return tableJoin.as(AccessWrite), true
+			}
+			return tableJoin.as(AccessRead), true
+		}
+	case StatementDelete:
+		switch keyword {
+		case "DELETE":
+			if strings.ToUpper(nextToken.Value) != "FROM" {
+				return tableSource.as(AccessWrite), true
+			}
+		case "FROM":
+			// once the deleted tables are known, FROM introduces the tables they are joined with
+			if found == 0 {
+				return tableSource.as(AccessWrite), true
+			}
+			return tableSource.as(AccessRead), true
+		case "USING":
+			return tableSource.as(AccessRead), true
+		case "JOIN":
+			return tableJoin.as(AccessRead), true
+		}
+	case StatementCopy:
+		switch keyword {
+		case "COPY":
+			if nextToken.Value != "(" {
+				return tableObject.as(AccessWrite), true
+			}
+		case "FROM":
+			// the query of COPY (SELECT ...) TO, as opposed to the source of the rows loaded into a table
+			if found == 0 {
+				return tableSource.as(AccessRead), true
+			}
+		case "JOIN":
+			return tableJoin.as(AccessRead), true
+		}
+	case StatementOptimize, StatementAttach, StatementDetach, StatementExists, StatementDescribe, StatementBackup, StatementRestore,
+		StatementVacuum, StatementCacheTable, StatementMsckRepair:
+		access := objectStatementAccess[statementType]
+		switch keyword {
+		case "TABLE", "VIEW":
+			if found == 0 {
+				return tableObject.as(access), true
+			}
+		case "EXISTS", "DESCRIBE", "DESC", "OPTIMIZE", "VACUUM":
+			// EXISTS t, DESCRIBE t and the delta OPTIMIZE t and VACUUM t name the table straight away
+			if nextToken.Type != TokenKeyword {
+				return tableObject.as(access), true
+			}
+		}
+	case StatementImport:
+		if keyword == "INTO" {
+			return tableObject.as(AccessWrite), true
+		}
+	case StatementShowStats:
+		if keyword == "FOR" {
+			return tableObject.as(AccessRead), true
+		}
+	case StatementCopyInto:
+		switch keyword {
+		case "INTO":
+			return tableObject.as(AccessWrite), true
+		case "FROM":
+			return tableObject.as(AccessRead), true
+		}
+	case StatementMerge:
+		switch keyword {
+		case "MERGE":
+			if strings.ToUpper(nextToken.Value) != "INTO" {
+				return tableJoin.as(AccessWrite), true
+			}
+		case "INTO":
+			return tableJoin.as(AccessWrite), true
+		case "USING":
+			return tableJoin.as(AccessRead), true
+		case "FROM":
+			return tableSource.as(AccessRead), true
+		case "JOIN":
+			return tableJoin.as(AccessRead), true
+		}
+	case StatementTruncate:
+		if keyword == "TRUNCATE" {
+			return tableList.as(AccessWrite), true
+		}
+	case StatementCreateTable, StatementCreateExternalTable, StatementCreateView, StatementAlterView:
+		switch keyword {
+		case "TABLE", "VIEW":
+			return tableObject.as(AccessDDL), true
+		case "FROM":
+			return tableSource.as(AccessRead), true
+		case "JOIN":
+			return tableJoin.as(AccessRead), true
+		}
+	case StatementAlterTable:
+		if keyword == "TABLE" {
+			return tableObject.as(AccessDDL), true
+		}
+	case StatementShowCreate:
+		if keyword == "TABLE" || keyword == "VIEW" {
+			return tableObject.as(AccessRead), true
+		}
+	case StatementDropTable, StatementDropView:
+		if keyword == "TABLE" || keyword == "VIEW" {
+			return tableList.as(AccessDDL), true
+		}
+	case StatementCreateIndex, StatementDropIndex, StatementAlterIndex,
+		StatementCreateTrigger, StatementDropTrigger, StatementAlterTrigger:
+		if keyword == "ON" && found == 0 {
+			return tableObject.as(AccessDDL), true
+		}
+	case StatementShowColumns, StatementShowIndex, StatementShowKeys:
+		if (keyword == "FROM" || keyword == "IN") && found == 0 {
+			return tableObject.as(AccessRead), true
+		}
+	case StatementGrant, StatementRevoke:
+		if keyword == "ON" && !slices.Contains(nonTableGrantObjects, strings.ToUpper(nextToken.Value)) {
+			return tableList.as(AccessDDL), true
+		}
+	}
+	return tableKeyword{}, false
+}
+
+type tableReaderState int
+
+const (
+	tableIdle tableReaderState = iota
+	tableExpectName
+	tableExpectDot
+	tableExpectAs
+	tableExpectAlias
+	tableExpectComma
+)
+
+// reads the (possibly qualified and aliased) table names that follow table keywords such as FROM
+type tableReader struct {
+	statement *Statement
+	dialect   Dialect
+	state     tableReaderState
+	keyword   tableKeyword
+	parts     []Token
+	pending   *TableRef
+	rawName   string
+	found     int
+	setSeen   bool
+}
+
+func newTableReader(statement *Statement, dialect Dialect) *tableReader {
+	return &tableReader{statement: statement, dialect: dialect}
+}
+
+// starts reading a table name with the next token
+func (r *tableReader) expect(keyword tableKeyword) {
+	r.state = tableExpectName
+	r.keyword = keyword
+	r.parts = nil
+	r.pending = nil
+}
+
+// feeds a token to the reader, returning true if it was consumed as part of a table reference
+func (r *tableReader) addToken(token Token, nextToken Token) bool {
+	if token.Type == TokenKeyword && strings.ToUpper(token.Value) == "SET" {
+		r.setSeen = true
+	}
+	if token.Type == TokenWhitespace || token.Type == TokenCommentInline || token.Type == TokenCommentBlock ||
+		token.Type == TokenExecutableComment {
+		return r.state != tableIdle
+	}
+
+	switch r.state {
+	case tableExpectName:
+		if slices.Contains(tableModifiers, strings.ToUpper(token.Value)) && len(r.parts) == 0 {
+			return true
+		}
+		// a clause word can end a statement where a table name is optional, but never replaces a required
+		// one or the part of a qualified name after its dot
+		required := r.keyword.required || len(r.parts) > 0
+		if !isIdentifierToken(token) || (!required && isClauseWord(token, r.dialect)) {
+			r.state = tableIdle
+			return false
+		}
+		r.parts = append(r.parts, token)
+		if nextToken.Value == "." {
+			r.state = tableExpectDot
+		} else {
+			r.finishName(nextToken)
+		}
+		return true
+	case tableExpectDot:
+		r.state = tableExpectName
+		return true
+	case tableExpectAs:
+		// cockroachdb's AS OF SYSTEM TIME follows the table rather than naming it
+		if isClauseWord(nextToken, r.dialect) || (r.dialect == DialectCockroachDB && strings.ToUpper(nextToken.Value) == "OF") {
+			r.commit(nextToken)
+			return true
+		}
+		r.state = tableExpectAlias
+		return true
+	case tableExpectAlias:
+		if isIdentifierToken(token) {
+			r.pending.Alias, r.pending.AliasQuoted = unquoteIdentifier(token.Value)
+		}
+		r.commit(nextToken)
+		return true
+	case tableExpectComma:
+		r.state = tableExpectName
+		r.keyword.required = true
+		return true
+	}
+	return false
+}
+
+func (r *tableReader) finishName(nextToken Token) {
+	r.pending, r.rawName = buildTableRef(r.parts, r.dialect)
+	r.pending.Access = r.keyword.access
+	r.parts = nil
+
+	if !r.keyword.alias {
+		r.commit(nextToken)
+	} else if strings.ToUpper(nextToken.Value) == "AS" {
+		r.state = tableExpectAs
+	} else if isAliasToken(nextToken, r.dialect) {
+		r.state = tableExpectAlias
+	} else {
+		r.commit(nextToken)
+	}
+}
+
+func (r *tableReader) commit(nextToken Token) {
+	// references to a CTE of the statement are not tables
+	if r.pending != nil && r.pending.Catalog == "" && r.pending.Schema == "" && r.isCteName(r.pending.Name, r.pending.NameQuoted) {
+		r.pending = nil
+	}
+
+	if r.pending != nil {
+		if !slices.Contains(r.statement.Tables, r.rawName) {
+			r.statement.Tables = append(r.statement.Tables, r.rawName)
+		}
+		r.addTableRef(*r.pending)
+		r.pending = nil
+		r.found++
+	}
+
+	if r.keyword.list && nextToken.Value == "," {
+		r.state = tableExpectComma
+	} else {
+		r.state = tableIdle
+	}
+}
+
+func (r *tableReader) isCteName(name string, quoted bool) bool {
+	for _, cte := range r.statement.CTEs {
+		if cte == name || (!quoted && strings.EqualFold(cte, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// adds the reference to the statement, merging its access into an identical reference already found
+func (r *tableReader) addTableRef(ref TableRef) {
+	for i, existing := range r.statement.TableRefs {
+		access := existing.Access
+		existing.Access = ref.Access
+		if existing == ref {
+			r.statement.TableRefs[i].Access = mergeTableAccess(access, ref.Access)
+			return
+		}
+	}
+	r.statement.TableRefs = append(r.statement.TableRefs, ref)
+}
+
+// changes the access of the tables found so far, once a later keyword tells how they are used
+func replaceTableAccess(refs []TableRef, from TableAccess, to TableAccess) {
+	for i := range refs {
+		if refs[i].Access == from {
+			refs[i].Access = to
+		}
+	}
+}
+
+// combines two ways a table is accessed; reading and writing the same table is READ_WRITE, and DDL
+// takes precedence over both
+func mergeTableAccess(a TableAccess, b TableAccess) TableAccess {
+	switch {
+	case a == b:
+		return a
+	case a == AccessDDL || b == AccessDDL:
+		return AccessDDL
+	default:
+		return AccessReadWrite
+	}
+}
+
+// builds a table reference from the dotted parts of its name, returning it along with the name as written
+func buildTableRef(parts []Token, dialect Dialect) (*TableRef, string) {
+	type namePart struct {
+		value  string
+		quoted bool
+	}
+
+	var names []namePart
+	var raw []string
+	for _, part := range parts {
+		raw = append(raw, part.Value)
+		value, quoted := unquoteIdentifier(part.Value)
+		// bigquery allows a whole path to be quoted at once, e.g. `project.dataset.table`
+		if dialect == DialectBigQuery && quoted && strings.HasPrefix(part.Value, "`") {
+			for _, segment := range strings.Split(value, ".") {
+				names = append(names, namePart{value: segment, quoted: true})
+			}
+			continue
+		}
+		names = append(names, namePart{value: value, quoted: quoted})
+	}
+
+	ref := &TableRef{}
+	last := len(names) - 1
+	ref.Name, ref.NameQuoted = names[last].value, names[last].quoted
+	if last >= 1 {
+		ref.Schema, ref.SchemaQuoted = names[last-1].value, names[last-1].quoted
+	}
+	if last >= 2 {
+		var catalog []string
+		for _, name := range names[:last-1] {
+			catalog = append(catalog, name.value)
+			ref.CatalogQuoted = ref.CatalogQuoted || name.quoted
+		}
+		ref.Catalog = strings.Join(catalog, ".")
+	}
+	return ref, strings.Join(raw, ".")
+}
+
+// removes the quotes around an identifier and unescapes doubled closing quotes
+func unquoteIdentifier(value string) (string, bool) {
+	if len(value) < 2 {
+		return value, false
+	}
+	endToken, ok := endTokens[rune(value[0])]
+	if !ok || value[0] == '\'' || rune(value[len(value)-1]) != endToken {
+		return value, false
+	}
+	closing := string(endToken)
+	return strings.ReplaceAll(value[1:len(value)-1], closing+closing, closing), true
+}
+
+func isIdentifierToken(token Token) bool {
+	if token.Value == "" {
+		return false
+	}
+	switch token.Type {
+	case TokenIdentifier, TokenQuotedIdentifier, TokenKeyword:
+		return true
+	}
+	return false
+}
+
+func isAliasToken(token Token, dialect Dialect) bool {
+	if token.Type == TokenKeyword {
+		return false
+	}
+	return isIdentifierToken(token) && !isClauseWord(token, dialect)
+}
+
+func isClauseWord(token Token, dialect Dialect) bool {
+	if !isLetter(rune(token.Value[0])) {
+		return false
+	}
+	word := strings.ToUpper(token.Value)
+	return clauseWords[word] || (dialect == DialectClickHouse && clickhouseClauseWords[word])
+}
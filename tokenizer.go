@@ -1,9 +1,14 @@
 package sqlqueryidentifier
 
 import (
+	"fmt"
+	"io"
+	"iter"
 	"slices"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 
 	regexp "github.com/wasilibs/go-re2"
 )
@@ -25,6 +30,14 @@ func init() {
 		"COLLATION", "ENGINE", "ENGINES", "ERRORS", "EVENTS", "GRANTS", "MASTER",
 		"OPEN", "PLUGINS", "PRIVILEGES", "PROCESSLIST", "PROFILE", "PROFILES",
 		"RELAYLOG", "REPLICAS", "SLAVE", "REPLICA", "TRIGGERS", "VARIABLES", "WARNINGS",
+		"START", "TRANSACTION", "TRAN", "WORK", "COMMIT", "ROLLBACK", "SAVEPOINT", "SAVE",
+		"RELEASE", "END", "ABORT", "GRANT", "REVOKE", "USER", "ROLE", "SET", "PASSWORD",
+		"MERGE", "REPLACE", "PACKAGE", "BODY", "TYPE", "COPY", "STAGE", "PIPE", "STREAM",
+		"TASK", "PUT", "GET", "USE", "WAREHOUSE", "SECONDARY", "EXEC", "EXECUTE", "IMMEDIATE", "OPTIMIZE", "SYSTEM",
+		"ATTACH", "DETACH", "EXISTS", "DESCRIBE", "DESC", "DICTIONARIES", "CLUSTERS", "CLUSTER",
+		"SETTINGS", "USERS", "ROLES", "FUNCTIONS", "UNLOAD", "VACUUM", "EXTERNAL", "IMPORT", "BACKUP",
+		"RESTORE", "RANGES", "PIVOT", "UNPIVOT", "EXPORT", "INSTALL", "LOAD", "CACHE", "MSCK", "OVERWRITE",
+		"SEQUENCE", "DOMAIN", "EXPLAIN", "CATALOGS", "SCHEMAS", "SESSION", "STATS", "PREPARE", "DEALLOCATE", "CALL",
 	}
 	for _, kw := range kwList {
 		keywords[kw] = true
@@ -33,6 +46,17 @@ func init() {
 
 var individuals = map[string]TokenType{
 	";": TokenSemicolon,
+	".": TokenDot,
+	",": TokenComma,
+	"(": TokenParenOpen,
+	")": TokenParenClose,
+}
+
+// operators of more than one character, longest first so that ->> is not read as -> and >
+var multiCharOperators = []string{
+	"->>", "#>>", "<=>", "!~*", "<<=", ">>=",
+	"::", "->", "#>", "<>", "!=", "<=", ">=", "||", "&&", "<<", ">>", "@>", "<@", "~*", "!~", "**", ":=",
+	"=>", "==", "@@", "?|", "?&", "#-",
 }
 
 var endTokens = map[rune]rune{
@@ -43,28 +67,150 @@ var endTokens = map[rune]rune{
 }
 
 func ScanToken(state *State, dialect Dialect, paramTypes *ParamTypes) Token {
+	if state.source == nil {
+		state.source = newSourceIndex(string(state.Input))
+	}
+	token := scanToken(state, dialect, paramTypes)
+	state.source.locateToken(&token)
+	return token
+}
+
+// reads the tokens of a query one at a time, without running the statement parser. Tokens are read
+// lexically: the rows of a COPY ... FROM stdin and client commands such as psql \connect, which only the
+// parser recognizes, come out as ordinary tokens
+type Tokenizer struct {
+	dialect    Dialect
+	paramTypes *ParamTypes
+	skipBlank  bool
+	state      *State
+	peeked     *Token
+}
+
+func NewTokenizer(query string, options TokenizerOptions) (*Tokenizer, error) {
+	dialect := DialectGeneric
+	if options.Dialect != nil {
+		dialect = *options.Dialect
+	}
+	if !slices.Contains(DIALECTS, dialect) {
+		return nil, fmt.Errorf("Unknown dialect. Allowed values: %v", DIALECTS)
+	}
+
+	paramTypes := options.ParamTypes
+	if paramTypes == nil {
+		paramTypes = DefaultParamTypesFor(dialect)
+	}
+	if err := validateCustomParams(paramTypes); err != nil {
+		return nil, err
+	}
+
+	state := initState([]rune(query), nil)
+	state.source = newSourceIndex(query)
+	return &Tokenizer{
+		dialect:    dialect,
+		paramTypes: paramTypes,
+		skipBlank:  options.SkipWhitespaceAndComments != nil && *options.SkipWhitespaceAndComments,
+		state:      state,
+	}, nil
+}
+
+// returns the next token and moves past it, or io.EOF once the query has been read
+func (t *Tokenizer) Next() (Token, error) {
+	if t.peeked != nil {
+		token := *t.peeked
+		t.peeked = nil
+		return token, nil
+	}
+	return t.scan()
+}
+
+// returns the next token without moving past it, or io.EOF once the query has been read
+func (t *Tokenizer) Peek() (Token, error) {
+	if t.peeked == nil {
+		token, err := t.scan()
+		if err != nil {
+			return Token{}, err
+		}
+		t.peeked = &token
+	}
+	return *t.peeked, nil
+}
+
+// iterates over the tokens that have not been read yet
+func (t *Tokenizer) All() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			token, err := t.Next()
+			if err != nil || !yield(token) {
+				return
+			}
+		}
+	}
+}
+
+func (t *Tokenizer) scan() (Token, error) {
+	for t.state.Position < t.state.End {
+		state := initState(nil, t.state)
+		token := ScanToken(state, t.dialect, t.paramTypes)
+		t.state = state
+		if t.skipBlank && isBlankToken(token) {
+			continue
+		}
+		return token, nil
+	}
+	return Token{}, io.EOF
+}
+
+func isBlankToken(token Token) bool {
+	return token.Type == TokenWhitespace || token.Type == TokenCommentInline || token.Type == TokenCommentBlock
+}
+
+func scanToken(state *State, dialect Dialect, paramTypes *ParamTypes) Token {
 	ch := read(state, 0)
 
+	if state.delimiter != "" {
+		if isDelimiter(state) {
+			return scanDelimiter(state)
+		}
+		// while a custom delimiter is active, semicolons are sent to the server as part of the statement
+		if ch == ';' {
+			return scanPunctuation(state)
+		}
+	}
+
+	if baseDialect(dialect) == DialectMySQL && isDelimiterDirective(state) {
+		return scanDelimiterDirective(state)
+	}
+
+	if baseDialect(dialect) == DialectMySQL {
+		if isExecutableCommentStart(ch, state, dialect) {
+			return scanExecutableCommentStart(state)
+		}
+		if state.executableComment && ch == '*' && peek(state) == '/' {
+			return scanExecutableCommentEnd(state)
+		}
+	}
+
+	if dialect == DialectOracle && isSlashTerminator(ch, state) {
+		return scanSlashTerminator(state)
+	}
+
 	if isWhitespace(ch) {
 		return scanWhitespace(state)
 	}
 
-	if isCommentInline(ch, state) {
+	if isCommentInline(ch, state, dialect) {
 		return scanCommentInline(state)
 	}
 
 	if isCommentBlock(ch, state) {
-		return scanCommentBlock(state)
+		return scanCommentBlock(state, dialect)
 	}
 
-	if isString(ch, dialect) {
-		endToken, ok := endTokens[ch]
-		if ok {
-			return scanString(state, endToken)
-		}
+	if literal, ok := findStringLiteral(state, dialect); ok {
+		return scanString(state, literal)
 	}
 
-	if isParameter(ch, state, paramTypes) {
+	if isParameter(ch, state, dialect, paramTypes) {
 		return scanParameter(state, dialect, paramTypes)
 	}
 
@@ -79,15 +225,23 @@ func ScanToken(state *State, dialect Dialect, paramTypes *ParamTypes) Token {
 		}
 	}
 
+	if dialect == DialectMSSQL && isBatchSeparator(state) {
+		return scanBatchSeparator(state)
+	}
+
 	if isLetter(ch) {
-		return scanWord(state)
+		return scanWord(state, dialect)
+	}
+
+	if isDigit(ch) || (ch == '.' && isDigit(peek(state))) {
+		return scanNumber(state)
 	}
 
 	if individual := scanIndividualCharacter(state); individual != nil {
 		return *individual
 	}
 
-	return skipChar(state)
+	return scanSymbol(state)
 }
 
 func read(state *State, skip int) rune {
@@ -147,7 +301,7 @@ func scanWhitespace(state *State) Token {
 		Type:  TokenWhitespace,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
 	}
 }
 
@@ -165,7 +319,7 @@ func scanCommentInline(state *State) Token {
 		Type:  TokenCommentInline,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
 	}
 }
 
@@ -204,27 +358,138 @@ func scanDollarQuotedString(state *State) Token {
 
 	value := string(state.Input[state.Start : state.Position+1])
 	return Token{
-		Type:  TokenString,
-		Value: value,
-		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		Type:        TokenString,
+		Value:       value,
+		Start:       state.Start,
+		End:         state.Start + utf8.RuneCountInString(value) - 1,
+		LiteralKind: LiteralDollarQuoted,
 	}
 }
 
-func scanString(state *State, endToken rune) Token {
+// how the string literal starting at the current token is quoted
+type stringLiteral struct {
+	kind      LiteralKind
+	prefix    int  // the length of the prefix before the opening quote
+	quote     rune // the opening quote
+	closing   rune // the closing delimiter of an oracle q'[...]' string, which ends before a quote
+	triple    bool // bigquery '''...''' and """...""" strings
+	backslash bool // a backslash escapes the character after it
+}
+
+// the string literal starting at the current token, with the prefixes and quotes of the dialect
+func findStringLiteral(state *State, dialect Dialect) (stringLiteral, bool) {
+	prefix := ""
+	i := state.Position
+	for i < len(state.Input) && len(prefix) < 2 && isLetter(state.Input[i]) && state.Input[i] != '_' {
+		prefix += strings.ToUpper(string(state.Input[i]))
+		i++
+	}
+	if i >= len(state.Input) || !isString(state.Input[i], dialect) {
+		return stringLiteral{}, false
+	}
+
+	literal := stringLiteral{prefix: len(prefix), quote: state.Input[i], backslash: hasBackslashEscapes(dialect)}
+	base := baseDialect(dialect)
+	switch {
+	case prefix == "":
+		literal.kind = LiteralStandard
+	case prefix == "N":
+		literal.kind = LiteralNational
+	case prefix == "E" && (base == DialectPSQL || dialect == DialectDuckDB):
+		literal.kind = LiteralEscape
+		literal.backslash = true
+	case prefix == "B" && dialect == DialectBigQuery:
+		literal.kind = LiteralBytes
+	case prefix == "B":
+		literal.kind = LiteralBit
+	case prefix == "X":
+		literal.kind = LiteralHex
+	case (prefix == "R" || prefix == "RB" || prefix == "BR") && dialect == DialectBigQuery, prefix == "R" && dialect == DialectSparkSQL:
+		literal.kind = LiteralRaw
+	case (prefix == "Q" || prefix == "NQ") && dialect == DialectOracle:
+		if i+1 >= len(state.Input) {
+			return stringLiteral{}, false
+		}
+		literal.kind = LiteralQuoteOperator
+		literal.closing = quoteOperatorClosing(state.Input[i+1])
+		literal.backslash = false
+		return literal, true
+	default:
+		return stringLiteral{}, false
+	}
+
+	if dialect == DialectBigQuery && i+2 < len(state.Input) && state.Input[i+1] == literal.quote && state.Input[i+2] == literal.quote {
+		literal.triple = true
+		if literal.kind == LiteralStandard {
+			literal.kind = LiteralTripleQuoted
+		}
+	}
+	return literal, true
+}
+
+// dialects whose quoted strings treat a backslash as an escape character
+func hasBackslashEscapes(dialect Dialect) bool {
+	return baseDialect(dialect) == DialectMySQL || dialect == DialectBigQuery || dialect == DialectSparkSQL ||
+		dialect == DialectClickHouse || dialect == DialectSnowflake
+}
+
+// the character closing an oracle q'...' string opened with the given one
+func quoteOperatorClosing(opening rune) rune {
+	switch opening {
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	case '(':
+		return ')'
+	case '<':
+		return '>'
+	}
+	return opening
+}
+
+func scanString(state *State, literal stringLiteral) Token {
+	state.Position += literal.prefix
+	if literal.triple {
+		state.Position += 2
+	}
+	if literal.closing != 0 {
+		state.Position++
+	}
+
 	var nextChar rune
 	for {
 		nextChar = read(state, 0)
-		if nextChar == endToken {
-			if peek(state) == endToken {
+		if nextChar == eof {
+			break
+		}
+		if literal.backslash && nextChar == '\\' {
+			read(state, 0)
+			continue
+		}
+		if literal.closing != 0 {
+			if nextChar == literal.closing && peek(state) == literal.quote {
 				read(state, 0)
-			} else {
 				break
 			}
+			continue
 		}
-		if nextChar == eof {
-			break
+		if nextChar != literal.quote {
+			continue
+		}
+		if literal.triple {
+			if state.Position+2 < len(state.Input) && state.Input[state.Position+1] == literal.quote &&
+				state.Input[state.Position+2] == literal.quote {
+				state.Position += 2
+				break
+			}
+			continue
 		}
+		if peek(state) == literal.quote {
+			read(state, 0)
+			continue
+		}
+		break
 	}
 
 	if nextChar == eof {
@@ -233,13 +498,39 @@ func scanString(state *State, endToken rune) Token {
 
 	value := string(state.Input[state.Start : state.Position+1])
 	return Token{
-		Type:  TokenString,
-		Value: value,
-		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		Type:        TokenString,
+		Value:       value,
+		Start:       state.Start,
+		End:         state.Start + utf8.RuneCountInString(value) - 1,
+		LiteralKind: literal.kind,
 	}
 }
 
+// compiled custom parameter patterns, so each of them is compiled once rather than for every token
+var customParamRegexes sync.Map
+
+func compileCustomParam(custom string) (*regexp.Regexp, error) {
+	if re, ok := customParamRegexes.Load(custom); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + custom + ")")
+	if err != nil {
+		return nil, err
+	}
+	customParamRegexes.Store(custom, re)
+	return re, nil
+}
+
+// returns an error for the first custom parameter that is not a valid regular expression
+func validateCustomParams(paramTypes *ParamTypes) error {
+	for _, custom := range paramTypes.Custom {
+		if _, err := compileCustomParam(custom); err != nil {
+			return fmt.Errorf("Invalid custom parameter %q: %w", custom, err)
+		}
+	}
+	return nil
+}
+
 func getCustomParam(state *State, paramTypes *ParamTypes) string {
 	if len(paramTypes.Custom) == 0 {
 		return ""
@@ -249,7 +540,10 @@ func getCustomParam(state *State, paramTypes *ParamTypes) string {
 		return ""
 	}
 	for _, r := range paramTypes.Custom {
-		re := regexp.MustCompile("^(?:" + r + ")")
+		re, err := compileCustomParam(r)
+		if err != nil {
+			continue
+		}
 		match := re.FindString(remainingInput)
 		if match != "" {
 			return match
@@ -298,7 +592,13 @@ func scanParameter(state *State, dialect Dialect, paramTypes *ParamTypes) Token
 	}
 
 	if !matched && len(paramTypes.Named) > 0 && slices.Contains(paramTypes.Named, curCh) {
-		if !isQuotedIdentifier(nextCh, dialect) {
+		if curCh == '$' && nextCh == '{' {
+			// spark ${var} substitution
+			for peek(state) != eof && state.Input[state.Position] != '}' {
+				read(state, 0)
+			}
+			matched = true
+		} else if !isQuotedIdentifier(nextCh, dialect) {
 			for isAlphaNumeric(peek(state)) {
 				read(state, 0)
 			}
@@ -318,6 +618,13 @@ func scanParameter(state *State, dialect Dialect, paramTypes *ParamTypes) Token
 		}
 	}
 
+	if !matched && paramTypes.Braced != nil && *paramTypes.Braced && curCh == '{' {
+		if length := bracedParamLength(state); length > 0 {
+			read(state, length-2)
+			matched = true
+		}
+	}
+
 	if !matched && len(paramTypes.Custom) > 0 {
 		custom := getCustomParam(state, paramTypes)
 		if custom != "" {
@@ -330,30 +637,44 @@ func scanParameter(state *State, dialect Dialect, paramTypes *ParamTypes) Token
 
 	isPositional := paramTypes.Positional != nil && *paramTypes.Positional
 	if !matched && !isPositional && curCh != '?' {
-		return Token{
-			Type:  TokenUnknown,
-			Value: value,
-			Start: state.Start,
-			End:   state.Start + len(value) - 1,
-		}
+		// not a parameter after all, so the character is read as the operator or punctuation it is
+		state.Position = state.Start
+		return scanSymbol(state)
 	}
 
 	return Token{
 		Type:  TokenParameter,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
 	}
 }
 
-func scanCommentBlock(state *State) Token {
+func scanCommentBlock(state *State, dialect Dialect) Token {
+	// psql and mssql block comments nest, so a comment only ends once each comment opened inside it has ended
+	nests := baseDialect(dialect) == DialectPSQL || dialect == DialectMSSQL
+	depth := 1
+
 	var nextChar, prevChar rune
 	for {
 		prevChar = nextChar
 		nextChar = read(state, 0)
-		if (prevChar != eof && nextChar != eof && string([]rune{prevChar, nextChar}) == "*/") || nextChar == eof {
+		if nextChar == eof {
 			break
 		}
+		if prevChar == '/' && nextChar == '*' && nests {
+			depth++
+			// the opening star cannot also close the comment it opens
+			nextChar = 0
+			continue
+		}
+		if prevChar == '*' && nextChar == '/' {
+			depth--
+			if depth == 0 || !nests {
+				break
+			}
+			nextChar = 0
+		}
 	}
 
 	value := string(state.Input[state.Start : state.Position+1])
@@ -361,7 +682,50 @@ func scanCommentBlock(state *State) Token {
 		Type:  TokenCommentBlock,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+// a mysql /*! ... */ executable comment (/*!40101 ... */ when versioned) or a mariadb /*M! ... */ one, whose
+// contents the server runs rather than ignores
+func isExecutableCommentStart(ch rune, state *State, dialect Dialect) bool {
+	if ch != '/' || state.Position+2 >= len(state.Input) || state.Input[state.Position+1] != '*' {
+		return false
+	}
+	next := state.Input[state.Position+2]
+	if next == 'M' && dialect == DialectMariaDB && state.Position+3 < len(state.Input) {
+		next = state.Input[state.Position+3]
+	}
+	return next == '!'
+}
+
+// scans the opener of an executable comment, including the version number that may follow it
+func scanExecutableCommentStart(state *State) Token {
+	for state.Input[state.Position] != '!' {
+		state.Position++
+	}
+	for peek(state) >= '0' && peek(state) <= '9' {
+		state.Position++
+	}
+	state.executableComment = true
+
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenExecutableComment,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+func scanExecutableCommentEnd(state *State) Token {
+	state.Position++
+	state.executableComment = false
+	return Token{
+		Type:  TokenExecutableComment,
+		Value: "*/",
+		Start: state.Start,
+		End:   state.Start + 1,
 	}
 }
 
@@ -369,7 +733,15 @@ func scanQuotedIdentifier(state *State, endToken rune) Token {
 	var nextChar rune
 	for {
 		nextChar = read(state, 0)
-		if nextChar == endToken || nextChar == eof {
+		if nextChar == endToken {
+			// a doubled closing quote is an escaped quote inside the identifier
+			if peek(state) == endToken {
+				read(state, 0)
+				continue
+			}
+			break
+		}
+		if nextChar == eof {
 			break
 		}
 	}
@@ -380,18 +752,18 @@ func scanQuotedIdentifier(state *State, endToken rune) Token {
 
 	value := string(state.Input[state.Start : state.Position+1])
 	return Token{
-		Type:  TokenKeyword,
+		Type:  TokenQuotedIdentifier,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
 	}
 }
 
-func scanWord(state *State) Token {
+func scanWord(state *State, dialect Dialect) Token {
 	var nextChar rune
 	for {
 		nextChar = read(state, 0)
-		if !isLetter(nextChar) {
+		if !isAlphaNumeric(nextChar) {
 			break
 		}
 	}
@@ -401,15 +773,115 @@ func scanWord(state *State) Token {
 	}
 
 	value := string(state.Input[state.Start : state.Position+1])
-	if !isKeyword(value) {
-		return skipWord(state, value)
+	if !isKeyword(value) && !isReservedWord(value, dialect) {
+		return scanIdentifier(state, value)
 	}
 
 	return Token{
 		Type:  TokenKeyword,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+// reads the rest of the line of the current token, leaving out the line break and any trailing whitespace
+func readToLineEnd(state *State) string {
+	for peek(state) != eof && peek(state) != '\n' && peek(state) != '\r' {
+		read(state, 0)
+	}
+	for state.Position > state.Start && isWhitespace(state.Input[state.Position]) {
+		state.Position--
+	}
+	return string(state.Input[state.Start : state.Position+1])
+}
+
+func scanDelimiterDirective(state *State) Token {
+	value := readToLineEnd(state)
+	delimiter := strings.Fields(value)[1]
+	if delimiter == ";" {
+		state.delimiter = ""
+	} else {
+		state.delimiter = delimiter
+	}
+
+	return Token{
+		Type:  TokenDelimiterDirective,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+func scanDelimiter(state *State) Token {
+	state.Position = state.Start + utf8.RuneCountInString(state.delimiter) - 1
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenDelimiter,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+func scanSlashTerminator(state *State) Token {
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenDelimiter,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start,
+	}
+}
+
+// extends the current token to the rest of its line, for commands run by the client rather than the server
+func scanClientCommand(state *State) Token {
+	value := readToLineEnd(state)
+	token := Token{
+		Type:  TokenClientCommand,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+	state.source.locateToken(&token)
+	return token
+}
+
+// scans the rows that follow a psql COPY ... FROM stdin statement, from the end of the statement's line
+// up to and including the \. line that ends them
+func scanCopyData(state *State) Token {
+	for peek(state) != eof {
+		for peek(state) != eof && peek(state) != '\n' {
+			read(state, 0)
+		}
+		if read(state, 0) == eof {
+			break
+		}
+		if isCopyDataEnd(state) {
+			state.Position += len(`\.`)
+			break
+		}
+	}
+
+	value := string(state.Input[state.Start : state.Position+1])
+	token := Token{
+		Type:  TokenCopyData,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+	state.source.locateToken(&token)
+	return token
+}
+
+func scanBatchSeparator(state *State) Token {
+	state.Position = state.Start + batchSeparatorLength(state) - 1
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenBatchSeparator,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
 	}
 }
 
@@ -424,7 +896,7 @@ func scanIndividualCharacter(state *State) *Token {
 		Type:  tokenType,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
 	}
 }
 
@@ -438,12 +910,91 @@ func skipChar(state *State) Token {
 	}
 }
 
-func skipWord(state *State, value string) Token {
+func scanIdentifier(state *State, value string) Token {
 	return Token{
-		Type:  TokenUnknown,
+		Type:  TokenIdentifier,
 		Value: value,
 		Start: state.Start,
-		End:   state.Start + len(value) - 1,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+// scans a decimal number with an optional fraction and exponent, or a 0x hexadecimal number
+func scanNumber(state *State) Token {
+	if state.Input[state.Position] == '0' && (peek(state) == 'x' || peek(state) == 'X') {
+		read(state, 0)
+		for isHexDigit(peek(state)) {
+			read(state, 0)
+		}
+	} else {
+		for isDigit(peek(state)) {
+			read(state, 0)
+		}
+		if state.Input[state.Position] != '.' && peek(state) == '.' {
+			read(state, 0)
+			for isDigit(peek(state)) {
+				read(state, 0)
+			}
+		}
+		if next := peek(state); next == 'e' || next == 'E' {
+			exponent := state.Position + 2
+			if exponent < len(state.Input) && (state.Input[exponent] == '+' || state.Input[exponent] == '-') {
+				exponent++
+			}
+			if exponent < len(state.Input) && isDigit(state.Input[exponent]) {
+				state.Position = exponent
+				for isDigit(peek(state)) {
+					read(state, 0)
+				}
+			}
+		}
+	}
+
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenNumber,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+func scanSymbol(state *State) Token {
+	ch := state.Input[state.Position]
+	if isOperator(ch) {
+		return scanOperator(state)
+	}
+	if isPunctuation(ch) {
+		return scanPunctuation(state)
+	}
+	return skipChar(state)
+}
+
+func scanOperator(state *State) Token {
+	remaining := state.Input[state.Start:]
+	for _, operator := range multiCharOperators {
+		if len(remaining) >= len(operator) && string(remaining[:len(operator)]) == operator {
+			state.Position = state.Start + len(operator) - 1
+			break
+		}
+	}
+
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenOperator,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start + utf8.RuneCountInString(value) - 1,
+	}
+}
+
+func scanPunctuation(state *State) Token {
+	value := string(state.Input[state.Start : state.Position+1])
+	return Token{
+		Type:  TokenPunctuation,
+		Value: value,
+		Start: state.Start,
+		End:   state.Start,
 	}
 }
 
@@ -451,18 +1002,142 @@ func isWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func isOperator(ch rune) bool {
+	return strings.ContainsRune("+-*/%=<>!~^&|@#?:", ch)
+}
+
+func isPunctuation(ch rune) bool {
+	return strings.ContainsRune("[]{}$\\`\"'", ch)
+}
+
 func isAlphaNumeric(ch rune) bool {
 	return ch != eof && (isLetter(ch) || (ch >= '0' && ch <= '9'))
 }
 
+// returns whether the current token is the first thing on its line
+func isLineStart(state *State) bool {
+	for i := state.Start - 1; i >= 0 && state.Input[i] != '\n'; i-- {
+		if !isWhitespace(state.Input[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// COPY data ends with a line holding only \.
+func isCopyDataEnd(state *State) bool {
+	i := state.Position + 1
+	if i+1 >= len(state.Input) || state.Input[i] != '\\' || state.Input[i+1] != '.' {
+		return false
+	}
+	return i+2 == len(state.Input) || state.Input[i+2] == '\n' || state.Input[i+2] == '\r'
+}
+
+// the mysql client changes the statement delimiter with a DELIMITER line, e.g. DELIMITER $$
+func isDelimiterDirective(state *State) bool {
+	input := state.Input
+	start := state.Start
+	if !isLineStart(state) || start+len("DELIMITER") >= len(input) ||
+		!strings.EqualFold(string(input[start:start+len("DELIMITER")]), "DELIMITER") {
+		return false
+	}
+
+	i := skipSpaces(input, start+len("DELIMITER"))
+	return i > start+len("DELIMITER") && i < len(input) && !isWhitespace(input[i])
+}
+
+func isDelimiter(state *State) bool {
+	end := state.Start + utf8.RuneCountInString(state.delimiter)
+	return end <= len(state.Input) && string(state.Input[state.Start:end]) == state.delimiter
+}
+
+// a slash on a line of its own ends the statement or PL/SQL unit before it in SQL*Plus
+func isSlashTerminator(ch rune, state *State) bool {
+	if ch != '/' || !isLineStart(state) {
+		return false
+	}
+	i := skipSpaces(state.Input, state.Start+1)
+	return i >= len(state.Input) || state.Input[i] == '\n' || state.Input[i] == '\r'
+}
+
+// GO on a line of its own, optionally followed by a repeat count, separates MSSQL batches
+func isBatchSeparator(state *State) bool {
+	return batchSeparatorLength(state) > 0
+}
+
+// returns the length of the GO [count] batch separator starting at the current token, or 0 if there is none
+func batchSeparatorLength(state *State) int {
+	input := state.Input
+	start := state.Start
+	if !isLineStart(state) {
+		return 0
+	}
+	if start+2 > len(input) || !strings.EqualFold(string(input[start:start+2]), "GO") {
+		return 0
+	}
+
+	end := start + 2
+	i := skipSpaces(input, end)
+	digits := i
+	for digits < len(input) && input[digits] >= '0' && input[digits] <= '9' {
+		digits++
+	}
+	if digits > i {
+		end = digits
+		i = skipSpaces(input, end)
+	}
+	if i < len(input) && input[i] != '\n' && input[i] != '\r' {
+		return 0
+	}
+	return end - start
+}
+
+func skipSpaces(input []rune, i int) int {
+	for i < len(input) && (input[i] == ' ' || input[i] == '\t') {
+		i++
+	}
+	return i
+}
+
 func isString(ch rune, dialect Dialect) bool {
 	stringStart := []rune{'\''}
-	if dialect == DialectMySQL {
+	if baseDialect(dialect) == DialectMySQL || dialect == DialectSparkSQL || dialect == DialectBigQuery {
 		stringStart = append(stringStart, '"')
 	}
 	return slices.Contains(stringStart, ch)
 }
 
+// the length of a clickhouse {name:Type} parameter starting at the current token, or 0 if there is none
+func bracedParamLength(state *State) int {
+	input := state.Input
+	i := skipSpaces(input, state.Start+1)
+	if i >= len(input) || !isLetter(input[i]) {
+		return 0
+	}
+	for i < len(input) && (isAlphaNumeric(input[i]) || input[i] == '_') {
+		i++
+	}
+	i = skipSpaces(input, i)
+	if i >= len(input) || input[i] != ':' {
+		return 0
+	}
+	for i < len(input) && input[i] != '}' && input[i] != '\n' {
+		i++
+	}
+	if i >= len(input) || input[i] != '}' {
+		return 0
+	}
+	return i - state.Start + 1
+}
+
 func isCustomParam(state *State, paramTypes *ParamTypes) bool {
 	remainingInput := string(state.Input[state.Start:])
 	if len(remainingInput) > maxRegexInputLength {
@@ -470,7 +1145,10 @@ func isCustomParam(state *State, paramTypes *ParamTypes) bool {
 	}
 
 	for _, r := range paramTypes.Custom {
-		re := regexp.MustCompile("^(?:" + r + ")")
+		re, err := compileCustomParam(r)
+		if err != nil {
+			continue
+		}
 		if re.MatchString(remainingInput) {
 			return true
 		}
@@ -478,17 +1156,23 @@ func isCustomParam(state *State, paramTypes *ParamTypes) bool {
 	return false
 }
 
-func isParameter(ch rune, state *State, paramTypes *ParamTypes) bool {
+func isParameter(ch rune, state *State, dialect Dialect, paramTypes *ParamTypes) bool {
 	if ch == eof {
 		return false
 	}
 	nextChar := peek(state)
 	prevChar := peekBack(state)
 
+	// :: is a cast
 	if ch == ':' && (prevChar == ':' || nextChar == ':') {
 		return false
 	}
 
+	// the file:///tmp/data.csv urls of snowflake PUT and GET are not named parameters
+	if dialect == DialectSnowflake && ch == ':' && nextChar == '/' {
+		return false
+	}
+
 	if paramTypes.Positional != nil && *paramTypes.Positional && ch == '?' {
 		return true
 	}
@@ -502,7 +1186,8 @@ func isParameter(ch rune, state *State, paramTypes *ParamTypes) bool {
 	}
 
 	if len(paramTypes.Named) > 0 {
-		if slices.Contains(paramTypes.Named, ch) {
+		// $$ and $tag$ open dollar-quoted strings rather than named parameters
+		if slices.Contains(paramTypes.Named, ch) && (ch != '$' || !isDollarQuotedString(state)) {
 			return true
 		}
 	}
@@ -512,6 +1197,10 @@ func isParameter(ch rune, state *State, paramTypes *ParamTypes) bool {
 		}
 	}
 
+	if paramTypes.Braced != nil && *paramTypes.Braced && ch == '{' && bracedParamLength(state) > 0 {
+		return true
+	}
+
 	if len(paramTypes.Custom) > 0 && isCustomParam(state, paramTypes) {
 		return true
 	}
@@ -534,7 +1223,10 @@ func isQuotedIdentifier(ch rune, dialect Dialect) bool {
 	return slices.Contains(startQuoteChars, ch)
 }
 
-func isCommentInline(ch rune, state *State) bool {
+func isCommentInline(ch rune, state *State, dialect Dialect) bool {
+	if ch == '#' && baseDialect(dialect) == DialectMySQL {
+		return true
+	}
 	if ch != '-' {
 		return false
 	}
@@ -1,11 +1,29 @@
 package sqlqueryidentifier
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
+// fills in the byte offsets and line/column positions of a token from its rune offsets
+func withPosition(input string, token Token) Token {
+	runes := []rune(input)
+	lineColumn := func(offset int) (int, int) {
+		before := string(runes[:offset])
+		return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+	}
+	token.StartByte = len(string(runes[:token.Start]))
+	token.EndByte = len(string(runes[:token.End+1])) - 1
+	token.StartLine, token.StartColumn = lineColumn(token.Start)
+	token.EndLine, token.EndColumn = lineColumn(token.End)
+	return token
+}
+
 func TestScanToken(t *testing.T) {
 	trueBool := true
 	genericParamTypes := &ParamTypes{Positional: &trueBool}
@@ -48,32 +66,65 @@ func TestScanToken(t *testing.T) {
 			expected:   Token{Type: TokenKeyword, Value: "SELECT", Start: 0, End: 5},
 		},
 		{
-			name:       `scans quoted keyword`,
+			name:       `scans quoted identifier`,
 			input:      `"ta;'` + "`" + `ble"`,
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenKeyword, Value: `"ta;'` + "`" + `ble"`, Start: 0, End: 9},
+			expected:   Token{Type: TokenQuotedIdentifier, Value: `"ta;'` + "`" + `ble"`, Start: 0, End: 9},
+		},
+		{
+			name:       "scans quoted identifier with escaped quotes",
+			input:      `"my ""quoted"" table"`,
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenQuotedIdentifier, Value: `"my ""quoted"" table"`, Start: 0, End: 20},
+		},
+		{
+			name:       "scans word with digits",
+			input:      "orders2024",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenIdentifier, Value: "orders2024", Start: 0, End: 9},
 		},
 		{
 			name:       "scans quoted string",
 			input:      `'some string; I "love it"'`,
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenString, Value: `'some string; I "love it"'`, Start: 0, End: 25},
+			expected:   Token{Type: TokenString, Value: `'some string; I "love it"'`, Start: 0, End: 25, LiteralKind: LiteralStandard},
 		},
 		{
 			name:       "scans quoted string with escaped quotes",
 			input:      `'''foo'' bar'`,
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenString, Value: `'''foo'' bar'`, Start: 0, End: 12},
+			expected:   Token{Type: TokenString, Value: `'''foo'' bar'`, Start: 0, End: 12, LiteralKind: LiteralStandard},
+		},
+		{
+			name:       "scans multi-byte string with byte offsets",
+			input:      "'café\n☕'",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected: Token{
+				Type:        TokenString,
+				Value:       "'café\n☕'",
+				Start:       0,
+				End:         7,
+				StartByte:   0,
+				EndByte:     10,
+				StartLine:   1,
+				StartColumn: 1,
+				EndLine:     2,
+				EndColumn:   2,
+				LiteralKind: LiteralStandard,
+			},
 		},
 		{
 			name:       "skips unknown tokens",
-			input:      "*",
+			input:      "§",
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenUnknown, Value: "*", Start: 0, End: 0},
+			expected:   Token{Type: TokenUnknown, Value: "§", Start: 0, End: 0},
 		},
 		{
 			name:       "scans ; individual identifier",
@@ -83,25 +134,340 @@ func TestScanToken(t *testing.T) {
 			expected:   Token{Type: TokenSemicolon, Value: ";", Start: 0, End: 0},
 		},
 		{
-			name:       "scans string with underscore as one token",
+			name:       "scans identifier with underscore as one token",
 			input:      "end_date",
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenUnknown, Value: "end_date", Start: 0, End: 7},
+			expected:   Token{Type: TokenIdentifier, Value: "end_date", Start: 0, End: 7},
 		},
 		{
 			name:       "scans dollar quoted string",
 			input:      "$$test$$",
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenString, Value: "$$test$$", Start: 0, End: 7},
+			expected:   Token{Type: TokenString, Value: "$$test$$", Start: 0, End: 7, LiteralKind: LiteralDollarQuoted},
 		},
 		{
 			name:       "scans dollar quoted string with label",
 			input:      "$aaa$test$aaa$",
 			dialect:    DialectGeneric,
 			paramTypes: genericParamTypes,
-			expected:   Token{Type: TokenString, Value: "$aaa$test$aaa$", Start: 0, End: 13},
+			expected:   Token{Type: TokenString, Value: "$aaa$test$aaa$", Start: 0, End: 13, LiteralKind: LiteralDollarQuoted},
+		},
+		{
+			name:       "scans mssql GO batch separator",
+			input:      "GO\n",
+			dialect:    DialectMSSQL,
+			paramTypes: DefaultParamTypesFor(DialectMSSQL),
+			expected:   Token{Type: TokenBatchSeparator, Value: "GO", Start: 0, End: 1},
+		},
+		{
+			name:       "scans mssql GO batch separator with a repeat count",
+			input:      "go 5  ",
+			dialect:    DialectMSSQL,
+			paramTypes: DefaultParamTypesFor(DialectMSSQL),
+			expected:   Token{Type: TokenBatchSeparator, Value: "go 5", Start: 0, End: 3},
+		},
+		{
+			name:       "scans GO followed by other words as a word",
+			input:      "GO TO",
+			dialect:    DialectMSSQL,
+			paramTypes: DefaultParamTypesFor(DialectMSSQL),
+			expected:   Token{Type: TokenIdentifier, Value: "GO", Start: 0, End: 1},
+		},
+		{
+			name:       "scans mysql DELIMITER directive",
+			input:      "DELIMITER $$\n",
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenDelimiterDirective, Value: "DELIMITER $$", Start: 0, End: 11},
+		},
+		{
+			name:       "scans oracle slash terminator line",
+			input:      "/\n",
+			dialect:    DialectOracle,
+			paramTypes: DefaultParamTypesFor(DialectOracle),
+			expected:   Token{Type: TokenDelimiter, Value: "/", Start: 0, End: 0},
+		},
+		{
+			name:       "scans clickhouse typed parameter",
+			input:      "{ids:Array(UInt32)}",
+			dialect:    DialectClickHouse,
+			paramTypes: DefaultParamTypesFor(DialectClickHouse),
+			expected:   Token{Type: TokenParameter, Value: "{ids:Array(UInt32)}", Start: 0, End: 18},
+		},
+		{
+			name:       "scans brace without a type as a single character",
+			input:      "{ids}",
+			dialect:    DialectClickHouse,
+			paramTypes: DefaultParamTypesFor(DialectClickHouse),
+			expected:   Token{Type: TokenPunctuation, Value: "{", Start: 0, End: 0},
+		},
+		{
+			name:       "scans spark variable substitution",
+			input:      "${db.var}",
+			dialect:    DialectSparkSQL,
+			paramTypes: DefaultParamTypesFor(DialectSparkSQL),
+			expected:   Token{Type: TokenParameter, Value: "${db.var}", Start: 0, End: 8},
+		},
+		{
+			name:       "scans spark double quoted string",
+			input:      "\"a b\"",
+			dialect:    DialectSparkSQL,
+			paramTypes: DefaultParamTypesFor(DialectSparkSQL),
+			expected:   Token{Type: TokenString, Value: "\"a b\"", Start: 0, End: 4, LiteralKind: LiteralStandard},
+		},
+		{
+			name:       "scans mysql hash comment",
+			input:      "# a; b\n",
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenCommentInline, Value: "# a; b\n", Start: 0, End: 6},
+		},
+		{
+			name:       "scans mysql executable comment opener with its version",
+			input:      "/*!40101 SET NAMES utf8 */",
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenExecutableComment, Value: "/*!40101", Start: 0, End: 7},
+		},
+		{
+			name:       "scans executable comment as a block comment outside of mysql",
+			input:      "/*!40101 SET NAMES utf8 */",
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenCommentBlock, Value: "/*!40101 SET NAMES utf8 */", Start: 0, End: 25},
+		},
+		{
+			name:       "scans mysql string with backslash escaped quote",
+			input:      `'it\'s; here'`,
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenString, Value: `'it\'s; here'`, Start: 0, End: 12, LiteralKind: LiteralStandard},
+		},
+		{
+			name:       "scans psql escape string",
+			input:      `E'it\'s; here'`,
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenString, Value: `E'it\'s; here'`, Start: 0, End: 13, LiteralKind: LiteralEscape},
+		},
+		{
+			name:       "scans national string",
+			input:      `N'a;b'`,
+			dialect:    DialectMSSQL,
+			paramTypes: DefaultParamTypesFor(DialectMSSQL),
+			expected:   Token{Type: TokenString, Value: `N'a;b'`, Start: 0, End: 5, LiteralKind: LiteralNational},
+		},
+		{
+			name:       "scans bit string",
+			input:      `B'0101'`,
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenString, Value: `B'0101'`, Start: 0, End: 6, LiteralKind: LiteralBit},
+		},
+		{
+			name:       "scans hex string",
+			input:      `X'0F'`,
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenString, Value: `X'0F'`, Start: 0, End: 4, LiteralKind: LiteralHex},
+		},
+		{
+			name:       "scans oracle quote operator string",
+			input:      `q'[it's; here]'`,
+			dialect:    DialectOracle,
+			paramTypes: DefaultParamTypesFor(DialectOracle),
+			expected:   Token{Type: TokenString, Value: `q'[it's; here]'`, Start: 0, End: 14, LiteralKind: LiteralQuoteOperator},
+		},
+		{
+			name:       "scans bigquery raw string",
+			input:      `r'a\'; b'`,
+			dialect:    DialectBigQuery,
+			paramTypes: DefaultParamTypesFor(DialectBigQuery),
+			expected:   Token{Type: TokenString, Value: `r'a\'; b'`, Start: 0, End: 8, LiteralKind: LiteralRaw},
+		},
+		{
+			name:       "scans bigquery bytes string",
+			input:      `b'x;'`,
+			dialect:    DialectBigQuery,
+			paramTypes: DefaultParamTypesFor(DialectBigQuery),
+			expected:   Token{Type: TokenString, Value: `b'x;'`, Start: 0, End: 4, LiteralKind: LiteralBytes},
+		},
+		{
+			name:       "scans bigquery triple quoted string",
+			input:      `'''it's; here'''`,
+			dialect:    DialectBigQuery,
+			paramTypes: DefaultParamTypesFor(DialectBigQuery),
+			expected:   Token{Type: TokenString, Value: `'''it's; here'''`, Start: 0, End: 15, LiteralKind: LiteralTripleQuoted},
+		},
+		{
+			name:       "scans bigquery triple double quoted string",
+			input:      `"""a "b"; c"""`,
+			dialect:    DialectBigQuery,
+			paramTypes: DefaultParamTypesFor(DialectBigQuery),
+			expected:   Token{Type: TokenString, Value: `"""a "b"; c"""`, Start: 0, End: 13, LiteralKind: LiteralTripleQuoted},
+		},
+		{
+			name:       "scans a word before a quote that is not a prefix of the dialect",
+			input:      "E'x'",
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenIdentifier, Value: "E", Start: 0, End: 0},
+		},
+		{
+			name:       "scans nested psql block comment",
+			input:      "/* outer /* inner */ still comment; */ SELECT 1",
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenCommentBlock, Value: "/* outer /* inner */ still comment; */", Start: 0, End: 37},
+		},
+		{
+			name:       "scans nested mssql block comment",
+			input:      "/* a /* b /* c */ */ */",
+			dialect:    DialectMSSQL,
+			paramTypes: DefaultParamTypesFor(DialectMSSQL),
+			expected:   Token{Type: TokenCommentBlock, Value: "/* a /* b /* c */ */ */", Start: 0, End: 22},
+		},
+		{
+			name:       "ends block comment at the first close in dialects without nesting",
+			input:      "/* outer /* inner */ still comment; */",
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenCommentBlock, Value: "/* outer /* inner */", Start: 0, End: 19},
+		},
+		{
+			name:       "scans an identifier",
+			input:      "orders",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenIdentifier, Value: "orders", Start: 0, End: 5},
+		},
+		{
+			name:       "scans a reserved word of the dialect as a keyword",
+			input:      "QUALIFY",
+			dialect:    DialectBigQuery,
+			paramTypes: DefaultParamTypesFor(DialectBigQuery),
+			expected:   Token{Type: TokenKeyword, Value: "QUALIFY", Start: 0, End: 6},
+		},
+		{
+			name:       "scans a word reserved only in other dialects as an identifier",
+			input:      "QUALIFY",
+			dialect:    DialectMySQL,
+			paramTypes: DefaultParamTypesFor(DialectMySQL),
+			expected:   Token{Type: TokenIdentifier, Value: "QUALIFY", Start: 0, End: 6},
+		},
+		{
+			name:       "scans an integer",
+			input:      "42",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenNumber, Value: "42", Start: 0, End: 1},
+		},
+		{
+			name:       "scans a decimal number with an exponent",
+			input:      "3.14e-10",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenNumber, Value: "3.14e-10", Start: 0, End: 7},
+		},
+		{
+			name:       "scans a number starting with a dot",
+			input:      ".5",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenNumber, Value: ".5", Start: 0, End: 1},
+		},
+		{
+			name:       "scans a hexadecimal number",
+			input:      "0x1F",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenNumber, Value: "0x1F", Start: 0, End: 3},
+		},
+		{
+			name:       "scans a psql cast operator",
+			input:      "::int",
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenOperator, Value: "::", Start: 0, End: 1},
+		},
+		{
+			name:       "scans the longest json operator",
+			input:      "->>'name'",
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenOperator, Value: "->>", Start: 0, End: 2},
+		},
+		{
+			name:       "scans a not equal operator",
+			input:      "<> 1",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenOperator, Value: "<>", Start: 0, End: 1},
+		},
+		{
+			name:       "scans a concatenation operator",
+			input:      "|| b",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenOperator, Value: "||", Start: 0, End: 1},
+		},
+		{
+			name:       "scans a single character operator",
+			input:      "*",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenOperator, Value: "*", Start: 0, End: 0},
+		},
+		{
+			name:       "scans a comma",
+			input:      ", b",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenComma, Value: ",", Start: 0, End: 0},
+		},
+		{
+			name:       "scans a dot",
+			input:      ".b",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenDot, Value: ".", Start: 0, End: 0},
+		},
+		{
+			name:       "scans an opening parenthesis",
+			input:      "(1)",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenParenOpen, Value: "(", Start: 0, End: 0},
+		},
+		{
+			name:       "scans a closing parenthesis",
+			input:      ")",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenParenClose, Value: ")", Start: 0, End: 0},
+		},
+		{
+			name:       "scans a bracket as punctuation",
+			input:      "[1]",
+			dialect:    DialectPSQL,
+			paramTypes: DefaultParamTypesFor(DialectPSQL),
+			expected:   Token{Type: TokenPunctuation, Value: "[", Start: 0, End: 0},
+		},
+		{
+			name:       "scans a bracketed mssql identifier",
+			input:      "[my table]",
+			dialect:    DialectMSSQL,
+			paramTypes: DefaultParamTypesFor(DialectMSSQL),
+			expected:   Token{Type: TokenQuotedIdentifier, Value: "[my table]", Start: 0, End: 9},
+		},
+		{
+			name:       "scans GO as a word outside of mssql",
+			input:      "GO",
+			dialect:    DialectGeneric,
+			paramTypes: genericParamTypes,
+			expected:   Token{Type: TokenIdentifier, Value: "GO", Start: 0, End: 1},
 		},
 	}
 
@@ -133,7 +499,7 @@ func TestScanToken(t *testing.T) {
 		input:      "$",
 		dialect:    DialectPSQL,
 		paramTypes: DefaultParamTypesFor(DialectPSQL),
-		expected:   Token{Type: TokenUnknown, Value: "$", Start: 0, End: 0},
+		expected:   Token{Type: TokenPunctuation, Value: "$", Start: 0, End: 0},
 	})
 
 	for _, chDialect := range []struct {
@@ -167,7 +533,7 @@ func TestScanToken(t *testing.T) {
 		input:      "$a",
 		dialect:    DialectPSQL,
 		paramTypes: DefaultParamTypesFor(DialectPSQL),
-		expected:   Token{Type: TokenUnknown, Value: "$", Start: 0, End: 0},
+		expected:   Token{Type: TokenPunctuation, Value: "$", Start: 0, End: 0},
 	})
 	testCases = append(testCases, testCase{
 		name:       "should not include trailing non-numbers for psql",
@@ -188,6 +554,18 @@ func TestScanToken(t *testing.T) {
 		dialect:    DialectMSSQL,
 		paramTypes: DefaultParamTypesFor(DialectMSSQL),
 		expected:   Token{Type: TokenParameter, Value: ":two", Start: 0, End: 3},
+	}, testCase{
+		name:       "should read a colon before a slash as a parameter for mssql",
+		input:      ":/x",
+		dialect:    DialectMSSQL,
+		paramTypes: DefaultParamTypesFor(DialectMSSQL),
+		expected:   Token{Type: TokenParameter, Value: ":", Start: 0, End: 0},
+	}, testCase{
+		name:       "should not read the colon of a file url as a parameter for snowflake",
+		input:      ":///tmp/data.csv",
+		dialect:    DialectSnowflake,
+		paramTypes: DefaultParamTypesFor(DialectSnowflake),
+		expected:   Token{Type: TokenOperator, Value: ":", Start: 0, End: 0},
 	})
 
 	allDialects := []Dialect{DialectMSSQL, DialectPSQL, DialectOracle, DialectBigQuery, DialectSQLite, DialectMySQL, DialectGeneric}
@@ -218,7 +596,7 @@ func TestScanToken(t *testing.T) {
 			input:      "$123hello",
 			dialect:    d,
 			paramTypes: numberedParamTypes,
-			expected:   Token{Type: TokenUnknown, Value: "$", Start: 0, End: 0},
+			expected:   Token{Type: TokenPunctuation, Value: "$", Start: 0, End: 0},
 		})
 	}
 
@@ -330,9 +708,136 @@ func TestScanToken(t *testing.T) {
 
 			token := ScanToken(state, tc.dialect, tc.paramTypes)
 
-			if !reflect.DeepEqual(token, tc.expected) {
-				t.Errorf("Expected token %+v, but got %+v", tc.expected, token)
+			expected := tc.expected
+			if expected.StartLine == 0 {
+				expected = withPosition(tc.input, expected)
+			}
+			if !reflect.DeepEqual(token, expected) {
+				t.Errorf("Expected token %+v, but got %+v", expected, token)
 			}
 		})
 	}
 }
+
+func TestTokenizer(t *testing.T) {
+	t.Run("should read every token of the query in order", func(t *testing.T) {
+		query := "SELECT a, 1 -- note\nFROM t"
+		tokenizer, err := NewTokenizer(query, TokenizerOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := []Token{
+			{Type: TokenKeyword, Value: "SELECT", Start: 0, End: 5},
+			{Type: TokenWhitespace, Value: " ", Start: 6, End: 6},
+			{Type: TokenIdentifier, Value: "a", Start: 7, End: 7},
+			{Type: TokenComma, Value: ",", Start: 8, End: 8},
+			{Type: TokenWhitespace, Value: " ", Start: 9, End: 9},
+			{Type: TokenNumber, Value: "1", Start: 10, End: 10},
+			{Type: TokenWhitespace, Value: " ", Start: 11, End: 11},
+			{Type: TokenCommentInline, Value: "-- note\n", Start: 12, End: 19},
+			{Type: TokenKeyword, Value: "FROM", Start: 20, End: 23},
+			{Type: TokenWhitespace, Value: " ", Start: 24, End: 24},
+			{Type: TokenIdentifier, Value: "t", Start: 25, End: 25},
+		}
+		for i := range expected {
+			expected[i] = withPosition(query, expected[i])
+		}
+
+		var actual []Token
+		for {
+			token, err := tokenizer.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			actual = append(actual, token)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected tokens %+v, but got %+v", expected, actual)
+		}
+	})
+
+	t.Run("should skip whitespace and comments", func(t *testing.T) {
+		skip := true
+		tokenizer, err := NewTokenizer("SELECT /* all */ *\nFROM t", TokenizerOptions{SkipWhitespaceAndComments: &skip})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var values []string
+		for token := range tokenizer.All() {
+			values = append(values, token.Value)
+		}
+		expected := []string{"SELECT", "*", "FROM", "t"}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("Expected tokens %q, but got %q", expected, values)
+		}
+	})
+
+	t.Run("should peek at the next token without reading it", func(t *testing.T) {
+		d := DialectPSQL
+		tokenizer, err := NewTokenizer("x::int", TokenizerOptions{Dialect: &d})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := tokenizer.Next(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		peeked, err := tokenizer.Peek()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		next, err := tokenizer.Next()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if peeked != next || next.Type != TokenOperator || next.Value != "::" {
+			t.Errorf("Expected to peek and then read the :: operator, but got %+v and %+v", peeked, next)
+		}
+
+		if _, err := tokenizer.Next(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := tokenizer.Peek(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected io.EOF after the last token, but got %v", err)
+		}
+		if _, err := tokenizer.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected io.EOF after the last token, but got %v", err)
+		}
+	})
+
+	t.Run("should stop iterating when the loop breaks", func(t *testing.T) {
+		tokenizer, err := NewTokenizer("SELECT 1; SELECT 2", TokenizerOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for token := range tokenizer.All() {
+			if token.Type == TokenSemicolon {
+				break
+			}
+		}
+		next, err := tokenizer.Next()
+		if err != nil || next.Type != TokenWhitespace || next.Start != 9 {
+			t.Errorf("Expected to resume after the semicolon, but got %+v (%v)", next, err)
+		}
+	})
+
+	t.Run("should reject an unknown dialect", func(t *testing.T) {
+		d := Dialect("nope")
+		if _, err := NewTokenizer("SELECT 1", TokenizerOptions{Dialect: &d}); err == nil || !strings.Contains(err.Error(), "Unknown dialect") {
+			t.Errorf("Expected an unknown dialect error, but got %v", err)
+		}
+	})
+
+	t.Run("should reject an invalid custom parameter", func(t *testing.T) {
+		paramTypes := &ParamTypes{Custom: []string{"("}}
+		if _, err := NewTokenizer("SELECT 1", TokenizerOptions{ParamTypes: paramTypes}); err == nil || !strings.Contains(err.Error(), "Invalid custom parameter") {
+			t.Errorf("Expected an invalid custom parameter error, but got %v", err)
+		}
+	})
+}
@@ -4,13 +4,21 @@ package sqlqueryidentifier
 type Dialect string
 
 const (
-	DialectMSSQL    Dialect = "mssql"
-	DialectSQLite   Dialect = "sqlite"
-	DialectMySQL    Dialect = "mysql"
-	DialectOracle   Dialect = "oracle"
-	DialectPSQL     Dialect = "psql"
-	DialectBigQuery Dialect = "bigquery"
-	DialectGeneric  Dialect = "generic"
+	DialectMSSQL       Dialect = "mssql"
+	DialectSQLite      Dialect = "sqlite"
+	DialectMySQL       Dialect = "mysql"
+	DialectOracle      Dialect = "oracle"
+	DialectPSQL        Dialect = "psql"
+	DialectBigQuery    Dialect = "bigquery"
+	DialectSnowflake   Dialect = "snowflake"
+	DialectClickHouse  Dialect = "clickhouse"
+	DialectRedshift    Dialect = "redshift"
+	DialectCockroachDB Dialect = "cockroachdb"
+	DialectDuckDB      Dialect = "duckdb"
+	DialectSparkSQL    Dialect = "sparksql"
+	DialectMariaDB     Dialect = "mariadb"
+	DialectTrino       Dialect = "trino"
+	DialectGeneric     Dialect = "generic"
 )
 
 var DIALECTS = []Dialect{
@@ -20,9 +28,32 @@ var DIALECTS = []Dialect{
 	DialectOracle,
 	DialectPSQL,
 	DialectBigQuery,
+	DialectSnowflake,
+	DialectClickHouse,
+	DialectRedshift,
+	DialectCockroachDB,
+	DialectDuckDB,
+	DialectSparkSQL,
+	DialectMariaDB,
+	DialectTrino,
 	DialectGeneric,
 }
 
+// dialects that follow the rules of another dialect and add statements of their own
+var dialectBases = map[Dialect]Dialect{
+	DialectRedshift:    DialectPSQL,
+	DialectCockroachDB: DialectPSQL,
+	DialectMariaDB:     DialectMySQL,
+}
+
+// returns the dialect whose rules the given dialect follows, or the dialect itself
+func baseDialect(dialect Dialect) Dialect {
+	if base, ok := dialectBases[dialect]; ok {
+		return base
+	}
+	return dialect
+}
+
 // represents the type of a SQL statement (e.g., SELECT, INSERT)
 type StatementType string
 
@@ -91,17 +122,111 @@ const (
 	StatementAlterProcedure  StatementType = "ALTER_PROCEDURE"
 	StatementAnonBlock       StatementType = "ANON_BLOCK"
 	StatementUnknown         StatementType = "UNKNOWN"
+
+	StatementBeginTransaction    StatementType = "BEGIN_TRANSACTION"
+	StatementCommit              StatementType = "COMMIT"
+	StatementRollback            StatementType = "ROLLBACK"
+	StatementSavepoint           StatementType = "SAVEPOINT"
+	StatementReleaseSavepoint    StatementType = "RELEASE_SAVEPOINT"
+	StatementRollbackToSavepoint StatementType = "ROLLBACK_TO_SAVEPOINT"
+
+	StatementGrant       StatementType = "GRANT"
+	StatementRevoke      StatementType = "REVOKE"
+	StatementCreateUser  StatementType = "CREATE_USER"
+	StatementAlterUser   StatementType = "ALTER_USER"
+	StatementDropUser    StatementType = "DROP_USER"
+	StatementCreateRole  StatementType = "CREATE_ROLE"
+	StatementAlterRole   StatementType = "ALTER_ROLE"
+	StatementDropRole    StatementType = "DROP_ROLE"
+	StatementSetPassword StatementType = "SET_PASSWORD"
+	StatementAlterOwner  StatementType = "ALTER_OWNER"
+
+	StatementAlterSequence StatementType = "ALTER_SEQUENCE"
+	StatementAlterType     StatementType = "ALTER_TYPE"
+	StatementAlterDomain   StatementType = "ALTER_DOMAIN"
+
+	StatementMerge   StatementType = "MERGE"
+	StatementReplace StatementType = "REPLACE"
+	StatementCopy    StatementType = "COPY"
+
+	StatementCreatePackage     StatementType = "CREATE_PACKAGE"
+	StatementCreatePackageBody StatementType = "CREATE_PACKAGE_BODY"
+	StatementCreateType        StatementType = "CREATE_TYPE"
+	StatementClientCommand     StatementType = "CLIENT_COMMAND"
+
+	StatementCreateStage       StatementType = "CREATE_STAGE"
+	StatementCreatePipe        StatementType = "CREATE_PIPE"
+	StatementCreateStream      StatementType = "CREATE_STREAM"
+	StatementCreateTask        StatementType = "CREATE_TASK"
+	StatementCopyInto          StatementType = "COPY_INTO"
+	StatementPut               StatementType = "PUT"
+	StatementGet               StatementType = "GET"
+	StatementUseWarehouse      StatementType = "USE_WAREHOUSE"
+	StatementUseDatabase       StatementType = "USE_DATABASE"
+	StatementUseSchema         StatementType = "USE_SCHEMA"
+	StatementUseRole           StatementType = "USE_ROLE"
+	StatementUseSecondaryRoles StatementType = "USE_SECONDARY_ROLES"
+	StatementExecuteImmediate  StatementType = "EXECUTE_IMMEDIATE"
+
+	StatementOptimize         StatementType = "OPTIMIZE"
+	StatementSystem           StatementType = "SYSTEM"
+	StatementAttach           StatementType = "ATTACH"
+	StatementDetach           StatementType = "DETACH"
+	StatementExists           StatementType = "EXISTS"
+	StatementDescribe         StatementType = "DESCRIBE"
+	StatementShowDictionaries StatementType = "SHOW_DICTIONARIES"
+	StatementShowClusters     StatementType = "SHOW_CLUSTERS"
+	StatementShowCluster      StatementType = "SHOW_CLUSTER"
+	StatementShowSettings     StatementType = "SHOW_SETTINGS"
+	StatementShowUsers        StatementType = "SHOW_USERS"
+	StatementShowRoles        StatementType = "SHOW_ROLES"
+	StatementShowFunctions    StatementType = "SHOW_FUNCTIONS"
+
+	StatementUnload                 StatementType = "UNLOAD"
+	StatementVacuum                 StatementType = "VACUUM"
+	StatementCreateExternalSchema   StatementType = "CREATE_EXTERNAL_SCHEMA"
+	StatementCreateExternalTable    StatementType = "CREATE_EXTERNAL_TABLE"
+	StatementCreateExternalFunction StatementType = "CREATE_EXTERNAL_FUNCTION"
+	StatementImport                 StatementType = "IMPORT"
+	StatementBackup                 StatementType = "BACKUP"
+	StatementRestore                StatementType = "RESTORE"
+	StatementShowRanges             StatementType = "SHOW_RANGES"
+
+	StatementExportDatabase StatementType = "EXPORT_DATABASE"
+	StatementImportDatabase StatementType = "IMPORT_DATABASE"
+	StatementInstall        StatementType = "INSTALL"
+	StatementLoad           StatementType = "LOAD"
+	StatementCacheTable     StatementType = "CACHE_TABLE"
+	StatementMsckRepair     StatementType = "MSCK_REPAIR"
+
+	StatementCreateSequence StatementType = "CREATE_SEQUENCE"
+	StatementDropSequence   StatementType = "DROP_SEQUENCE"
+	StatementShowExplain    StatementType = "SHOW_EXPLAIN"
+
+	StatementPrepare      StatementType = "PREPARE"
+	StatementExecute      StatementType = "EXECUTE"
+	StatementDeallocate   StatementType = "DEALLOCATE"
+	StatementCall         StatementType = "CALL"
+	StatementSetSession   StatementType = "SET_SESSION"
+	StatementShowCatalogs StatementType = "SHOW_CATALOGS"
+	StatementShowSchemas  StatementType = "SHOW_SCHEMAS"
+	StatementShowSession  StatementType = "SHOW_SESSION"
+	StatementShowStats    StatementType = "SHOW_STATS"
 )
 
 // represents the behavior of a statement (e.g., LISTING, MODIFICATION)
 type ExecutionType string
 
 const (
-	ExecutionListing      ExecutionType = "LISTING"
-	ExecutionModification ExecutionType = "MODIFICATION"
-	ExecutionInformation  ExecutionType = "INFORMATION"
-	ExecutionAnonBlock    ExecutionType = "ANON_BLOCK"
-	ExecutionUnknown      ExecutionType = "UNKNOWN"
+	ExecutionListing       ExecutionType = "LISTING"
+	ExecutionModification  ExecutionType = "MODIFICATION"
+	ExecutionInformation   ExecutionType = "INFORMATION"
+	ExecutionAnonBlock     ExecutionType = "ANON_BLOCK"
+	ExecutionTransaction   ExecutionType = "TRANSACTION"
+	ExecutionPermission    ExecutionType = "PERMISSION"
+	ExecutionClientCommand ExecutionType = "CLIENT_COMMAND"
+	ExecutionSession       ExecutionType = "SESSION"
+	ExecutionUnknown       ExecutionType = "UNKNOWN"
 )
 
 type ParamTypes struct {
@@ -110,6 +235,7 @@ type ParamTypes struct {
 	Named      []rune // ':' | '@' | '$'
 	Quoted     []rune // ':' | '@' | '$'
 	Custom     []string
+	Braced     *bool // clickhouse {name:Type}
 }
 
 // provides configuration for the Identify function
@@ -120,17 +246,71 @@ type IdentifyOptions struct {
 	ParamTypes     *ParamTypes
 }
 
-// represents a single parsed SQL statement
+// configures a Tokenizer. The dialect defaults to generic and the parameter types to the defaults of the
+// dialect; SkipWhitespaceAndComments leaves whitespace and comment tokens out of the stream
+type TokenizerOptions struct {
+	Dialect                   *Dialect
+	ParamTypes                *ParamTypes
+	SkipWhitespaceAndComments *bool
+}
+
+// represents a single parsed SQL statement; Start and End are rune offsets, StartByte and EndByte are
+// byte offsets into the query, and lines and columns are 1-based
 type IdentifyResult struct {
 	Start         int           `json:"start"`
 	End           int           `json:"end"`
+	StartByte     int           `json:"startByte"`
+	EndByte       int           `json:"endByte"`
+	StartLine     int           `json:"startLine"`
+	StartColumn   int           `json:"startColumn"`
+	EndLine       int           `json:"endLine"`
+	EndColumn     int           `json:"endColumn"`
 	Text          string        `json:"text"`
 	Type          StatementType `json:"type"`
 	ExecutionType ExecutionType `json:"executionType"`
 	Parameters    []string      `json:"parameters"`
 	Tables        []string      `json:"tables"`
+	TableRefs     []TableRef    `json:"tableRefs,omitempty"`
+	CTEs          []string      `json:"ctes,omitempty"`
+	ModifyingCTEs []string      `json:"modifyingCtes,omitempty"`
+	Upsert        bool          `json:"upsert,omitempty"`
+	Batch         int           `json:"batch"`
+	CopyData      string        `json:"copyData,omitempty"`
+}
+
+// a group of statements that MSSQL tools send to the server together, ended by a GO line;
+// Repeat is the number of times the batch is run, as given by GO n
+type Batch struct {
+	Index      int              `json:"index"`
+	Repeat     int              `json:"repeat"`
+	Statements []IdentifyResult `json:"statements"`
 }
 
+// a table referenced by a statement, split into its qualified parts with any quotes removed;
+// the Quoted flags tell whether each part was written as a quoted identifier, and Access tells
+// whether the statement reads, writes or changes the definition of the table
+type TableRef struct {
+	Catalog       string      `json:"catalog,omitempty"`
+	Schema        string      `json:"schema,omitempty"`
+	Name          string      `json:"name"`
+	Alias         string      `json:"alias,omitempty"`
+	CatalogQuoted bool        `json:"catalogQuoted,omitempty"`
+	SchemaQuoted  bool        `json:"schemaQuoted,omitempty"`
+	NameQuoted    bool        `json:"nameQuoted,omitempty"`
+	AliasQuoted   bool        `json:"aliasQuoted,omitempty"`
+	Access        TableAccess `json:"access"`
+}
+
+// describes how a statement uses a table
+type TableAccess string
+
+const (
+	AccessRead      TableAccess = "READ"
+	AccessWrite     TableAccess = "WRITE"
+	AccessReadWrite TableAccess = "READ_WRITE"
+	AccessDDL       TableAccess = "DDL"
+)
+
 type Statement struct {
 	Start         int
 	End           int
@@ -143,21 +323,34 @@ type Statement struct {
 	SQLSecurity   *int
 	Parameters    []string
 	Tables        []string
+	TableRefs     []TableRef
+	CTEs          []string
+	ModifyingCTEs []string
+	Upsert        bool
+	Batch         int
 	IsCte         *bool
+
+	copyFromStdin bool // whether the rows of a COPY statement follow it in the input
+	endsWithLine  bool // whether the statement ends at the end of its line, like a SQL*Plus command
 }
 
 func (s *Statement) ToConcrete() ConcreteStatement {
 	cs := ConcreteStatement{
-		Start:        s.Start,
-		End:          s.End,
-		EndStatement: s.EndStatement,
-		CanEnd:       s.CanEnd,
-		Definer:      s.Definer,
-		Algorithm:    s.Algorithm,
-		SQLSecurity:  s.SQLSecurity,
-		Parameters:   s.Parameters,
-		Tables:       s.Tables,
-		IsCte:        s.IsCte,
+		Start:         s.Start,
+		End:           s.End,
+		EndStatement:  s.EndStatement,
+		CanEnd:        s.CanEnd,
+		Definer:       s.Definer,
+		Algorithm:     s.Algorithm,
+		SQLSecurity:   s.SQLSecurity,
+		Parameters:    s.Parameters,
+		Tables:        s.Tables,
+		TableRefs:     s.TableRefs,
+		CTEs:          s.CTEs,
+		ModifyingCTEs: s.ModifyingCTEs,
+		Upsert:        s.Upsert,
+		Batch:         s.Batch,
+		IsCte:         s.IsCte,
 	}
 	if s.Type != nil {
 		cs.Type = *s.Type
@@ -184,7 +377,13 @@ type ConcreteStatement struct {
 	SQLSecurity   *int
 	Parameters    []string
 	Tables        []string
+	TableRefs     []TableRef
+	CTEs          []string
+	ModifyingCTEs []string
+	Upsert        bool
+	Batch         int
 	IsCte         *bool
+	CopyData      string
 }
 
 type State struct {
@@ -192,6 +391,11 @@ type State struct {
 	End      int
 	Position int
 	Input    []rune
+
+	source    *sourceIndex
+	delimiter string // the custom statement delimiter set by a mysql DELIMITER line
+
+	executableComment bool // inside a mysql /*! ... */ comment, whose contents the server runs
 }
 
 type TokenType string
@@ -206,15 +410,57 @@ const (
 	TokenParameter     TokenType = "parameter"
 	TokenTable         TokenType = "table"
 	TokenUnknown       TokenType = "unknown"
+
+	TokenIdentifier       TokenType = "identifier"
+	TokenQuotedIdentifier TokenType = "quoted-identifier"
+	TokenNumber           TokenType = "number"
+	TokenOperator         TokenType = "operator"
+	TokenPunctuation      TokenType = "punctuation"
+	TokenDot              TokenType = "dot"
+	TokenComma            TokenType = "comma"
+	TokenParenOpen        TokenType = "paren-open"
+	TokenParenClose       TokenType = "paren-close"
+
+	TokenBatchSeparator     TokenType = "batch-separator"
+	TokenDelimiter          TokenType = "delimiter"
+	TokenDelimiterDirective TokenType = "delimiter-directive"
+	TokenClientCommand      TokenType = "client-command"
+	TokenCopyData           TokenType = "copy-data"
+	TokenExecutableComment  TokenType = "executable-comment"
 )
 
+// represents a single token; Start and End are rune offsets, StartByte and EndByte are byte offsets
 type Token struct {
-	Type  TokenType `json:"type"`
-	Value string    `json:"value"`
-	Start int       `json:"start"`
-	End   int       `json:"end"`
+	Type        TokenType `json:"type"`
+	Value       string    `json:"value"`
+	Start       int       `json:"start"`
+	End         int       `json:"end"`
+	StartByte   int       `json:"startByte"`
+	EndByte     int       `json:"endByte"`
+	StartLine   int       `json:"startLine"`
+	StartColumn int       `json:"startColumn"`
+	EndLine     int       `json:"endLine"`
+	EndColumn   int       `json:"endColumn"`
+
+	LiteralKind LiteralKind `json:"literalKind,omitempty"` // set on string tokens
 }
 
+// the kind of a string literal, read from its prefix and quotes
+type LiteralKind string
+
+const (
+	LiteralStandard      LiteralKind = "standard"       // '...', and "..." where double quotes make strings
+	LiteralEscape        LiteralKind = "escape"         // psql E'...', with backslash escapes
+	LiteralNational      LiteralKind = "national"       // N'...'
+	LiteralBit           LiteralKind = "bit"            // B'...'
+	LiteralHex           LiteralKind = "hex"            // X'...'
+	LiteralBytes         LiteralKind = "bytes"          // bigquery b'...'
+	LiteralRaw           LiteralKind = "raw"            // bigquery and spark r'...', bigquery rb'...'
+	LiteralTripleQuoted  LiteralKind = "triple-quoted"  // bigquery '''...''' and """..."""
+	LiteralQuoteOperator LiteralKind = "quote-operator" // oracle q'[...]'
+	LiteralDollarQuoted  LiteralKind = "dollar-quoted"  // psql $tag$...$tag$
+)
+
 type ParseResult struct {
 	Type   string              `json:"type"`
 	Start  int                 `json:"start"`
@@ -233,6 +479,23 @@ type AcceptToken struct {
 	Value string
 }
 
+// describes why a query could not be parsed and where it happened
+type ParseError struct {
+	Message        string        `json:"message"`
+	Token          Token         `json:"token"`
+	Offset         int           `json:"offset"`
+	ByteOffset     int           `json:"byteOffset"`
+	Line           int           `json:"line"`
+	Column         int           `json:"column"`
+	StatementIndex int           `json:"statementIndex"`
+	Step           int           `json:"step"`
+	Expected       []AcceptToken `json:"expected"`
+}
+
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
 type Step struct {
 	PreCanGoToNext  func(token *Token) bool
 	Validation      *StepValidation